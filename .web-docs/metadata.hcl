@@ -28,4 +28,14 @@ integration {
     name = "IONOS Cloud"
     slug = "ionoscloud"
   }
+  component {
+    type = "data-source"
+    name = "IONOS Cloud Image"
+    slug = "image"
+  }
+  component {
+    type = "data-source"
+    name = "IONOS Cloud Snapshot"
+    slug = "snapshot"
+  }
 }
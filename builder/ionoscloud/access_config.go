@@ -0,0 +1,190 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// AccessConfig holds the credentials and API settings shared by the builder
+// and the data sources.
+type AccessConfig struct {
+	IonosUsername   string `mapstructure:"username"`
+	IonosPassword   string `mapstructure:"password"`
+	IonosToken      string `mapstructure:"token"`
+	IonosApiUrl     string `mapstructure:"url"`
+	Profile         string `mapstructure:"profile"`
+	CredentialsFile string `mapstructure:"credentials_file"`
+
+	Retries      int           `mapstructure:"retries"`
+	RetryWaitMin time.Duration `mapstructure:"retry_wait_min"`
+	RetryWaitMax time.Duration `mapstructure:"retry_wait_max"`
+}
+
+// Prepare fills in the credentials from the environment and the credentials
+// file, sets the defaults and validates the result.
+func (c *AccessConfig) Prepare() ([]string, []error) {
+	var errs []error
+	var warnings []string
+
+	// a token from any source wins, otherwise the username and password are
+	// filled in field by field from the config, the environment and the
+	// credentials file
+	if c.IonosToken == "" {
+		c.IonosToken = os.Getenv("IONOS_TOKEN")
+	}
+	if c.IonosToken == "" {
+		if c.IonosUsername == "" {
+			c.IonosUsername = os.Getenv("IONOS_USERNAME")
+		}
+		if c.IonosPassword == "" {
+			c.IonosPassword = os.Getenv("IONOS_PASSWORD")
+		}
+	}
+
+	if c.IonosApiUrl == "" {
+		c.IonosApiUrl = os.Getenv("IONOS_API_URL")
+	}
+
+	if c.Profile == "" {
+		c.Profile = os.Getenv("IONOS_PROFILE")
+	}
+	if c.CredentialsFile == "" {
+		c.CredentialsFile = os.Getenv("IONOS_CREDENTIALS_FILE")
+	}
+	if !c.hasCredentials() {
+		if err := c.loadCredentialsProfile(); err != nil {
+			errs = append(errs, err)
+		}
+	} else if c.Profile != "" || c.CredentialsFile != "" {
+		warnings = append(warnings, "credentials are set in the config or the environment, the credentials file is not used")
+	}
+
+	if c.IonosApiUrl == "" {
+		c.IonosApiUrl = "https://api.ionos.com"
+	}
+
+	if c.Retries == 0 {
+		c.Retries = 3
+	}
+
+	if c.RetryWaitMin == 0 {
+		c.RetryWaitMin = 1 * time.Second
+	}
+
+	if c.RetryWaitMax == 0 {
+		c.RetryWaitMax = 30 * time.Second
+	}
+
+	if c.Retries < -1 {
+		errs = append(errs, errors.New("'retries' must be -1 to disable retries or greater"))
+	}
+
+	if c.RetryWaitMin > c.RetryWaitMax {
+		errs = append(errs, errors.New("'retry_wait_min' must not be greater than 'retry_wait_max'"))
+	}
+
+	if c.IonosToken == "" {
+		if c.IonosUsername == "" {
+			errs = append(errs, errors.New("IONOS token or username is required"))
+		}
+
+		if c.IonosPassword == "" {
+			errs = append(errs, errors.New("IONOS password is required"))
+		}
+	} else if c.IonosUsername != "" {
+		warnings = append(warnings, "both IONOS token and username are set, the token takes precedence")
+	}
+
+	packersdk.LogSecretFilter.Set(c.IonosUsername, c.IonosPassword, c.IonosToken)
+
+	return warnings, errs
+}
+
+// hasCredentials reports whether a token or both a username and password
+// are set.
+func (c *AccessConfig) hasCredentials() bool {
+	return c.IonosToken != "" || (c.IonosUsername != "" && c.IonosPassword != "")
+}
+
+// loadCredentialsProfile reads the credentials from a profile of the local
+// credentials file. It is only used if the config and the environment do not
+// set complete credentials; a token in the profile wins, otherwise only the
+// missing username or password is taken from it. The profile and file default to "default"
+// and ~/.ionos/credentials. A missing default file is not an error, but a
+// profile or file that was asked for explicitly must exist.
+func (c *AccessConfig) loadCredentialsProfile() error {
+	explicit := c.Profile != "" || c.CredentialsFile != ""
+
+	name := c.Profile
+	if name == "" {
+		name = defaultProfileName
+	}
+	path := c.CredentialsFile
+	if path == "" {
+		path = defaultCredentialsFile()
+	}
+	if path == "" {
+		return nil
+	}
+
+	profile, err := loadProfile(path, name)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
+		return fmt.Errorf("error reading IONOS credentials file: %w", err)
+	}
+	if profile == nil {
+		if explicit {
+			return fmt.Errorf("profile %q not found in %s", name, path)
+		}
+		return nil
+	}
+
+	if profile.Token != "" {
+		c.IonosToken = profile.Token
+	} else {
+		if c.IonosUsername == "" {
+			c.IonosUsername = profile.Username
+		}
+		if c.IonosPassword == "" {
+			c.IonosPassword = profile.Password
+		}
+	}
+	if c.IonosApiUrl == "" {
+		c.IonosApiUrl = profile.Url
+	}
+	return nil
+}
+
+// Client returns an API client for the configured credentials that retries
+// failed requests as configured.
+func (c *AccessConfig) Client() *ionoscloud.APIClient {
+	cfg := ionoscloud.NewConfiguration(c.IonosUsername, c.IonosPassword, c.IonosToken, c.IonosApiUrl)
+	cfg.SetDepth(5)
+	cfg.HTTPClient = &http.Client{}
+	// retries are handled by retryTransport, disable the SDK's own retry loop
+	cfg.MaxRetries = 1
+
+	// new apiclient for ionoscloud
+	client := ionoscloud.NewAPIClient(cfg)
+	// NewAPIClient may install its own transport for certificate pinning, so
+	// wrap whatever it ended up with
+	// 0 is replaced by the default in Prepare, -1 turns retries off
+	retries := c.Retries
+	if retries < 0 {
+		retries = 0
+	}
+	cfg.HTTPClient.Transport = newRetryTransport(cfg.HTTPClient.Transport, retries, c.RetryWaitMin, c.RetryWaitMax)
+
+	return client
+}
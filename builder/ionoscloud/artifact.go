@@ -4,15 +4,41 @@
 package ionoscloud
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"strings"
+
+	registryimage "github.com/hashicorp/packer-plugin-sdk/packer/registry/image"
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
 )
 
 type Artifact struct {
-	snapshotData string
+	// snapshotId is the UUID of the created snapshot
+	snapshotId string
+	// snapshotName is the name of the created snapshot
+	snapshotName string
+	// location is the location the snapshot was created in, e.g. de/fra
+	location string
+	// sourceImageId is the ID of the image the build volume was created from
+	sourceImageId string
+	// labels are additional build details reported to the HCP Packer registry
+	labels map[string]string
+	// dataSnapshots are the snapshots taken of additional data volumes
+	dataSnapshots []artifactSnapshot
 
 	// StateData should store data such as GeneratedData
 	// to be shared with post-processors
 	StateData map[string]interface{}
+
+	client *ionoscloud.APIClient
+}
+
+// artifactSnapshot is a snapshot of a data volume of the build server.
+type artifactSnapshot struct {
+	id     string
+	name   string
+	volume string
 }
 
 func (*Artifact) BuilderId() string {
@@ -23,18 +49,110 @@ func (a *Artifact) Files() []string {
 	return []string{}
 }
 
-func (*Artifact) Id() string {
-	return "Null"
+// Id returns "location:id" for the snapshot of the boot volume, followed by
+// the snapshots of the data volumes, separated by commas. It is empty if no
+// snapshot was created.
+func (a *Artifact) Id() string {
+	if a.snapshotId == "" {
+		return ""
+	}
+	ids := []string{fmt.Sprintf("%s:%s", a.location, a.snapshotId)}
+	for _, s := range a.dataSnapshots {
+		ids = append(ids, fmt.Sprintf("%s:%s", a.location, s.id))
+	}
+	return strings.Join(ids, ",")
 }
 
 func (a *Artifact) String() string {
-	return fmt.Sprintf("A snapshot was created: '%v'", a.snapshotData)
+	if a.snapshotName == "" {
+		return "No snapshot was created"
+	}
+	if len(a.dataSnapshots) == 0 {
+		return fmt.Sprintf("A snapshot was created: '%v'", a.snapshotName)
+	}
+	names := []string{fmt.Sprintf("'%v'", a.snapshotName)}
+	for _, s := range a.dataSnapshots {
+		names = append(names, fmt.Sprintf("'%v' (%s)", s.name, s.volume))
+	}
+	return fmt.Sprintf("Snapshots were created: %s", strings.Join(names, ", "))
 }
 
 func (a *Artifact) State(name string) interface{} {
+	if name == registryimage.ArtifactStateURI {
+		return a.stateHCPPackerRegistryMetadata()
+	}
 	return a.StateData[name]
 }
 
+// stateHCPPackerRegistryMetadata returns the image metadata stored on the
+// HCP Packer registry for this build, one image per snapshot, or nil if no
+// snapshot was created.
+func (a *Artifact) stateHCPPackerRegistryMetadata() interface{} {
+	if a.snapshotId == "" {
+		return nil
+	}
+	labels := make(map[string]interface{}, len(a.labels))
+	for k, v := range a.labels {
+		labels[k] = v
+	}
+	img, _ := registryimage.FromArtifact(a,
+		registryimage.WithID(a.snapshotId),
+		registryimage.WithProvider("ionoscloud"),
+		registryimage.WithRegion(a.location),
+		registryimage.WithSourceID(a.sourceImageId),
+		registryimage.SetLabels(labels),
+	)
+	if len(a.dataSnapshots) == 0 {
+		return img
+	}
+
+	images := []*registryimage.Image{img}
+	for _, s := range a.dataSnapshots {
+		dataImg, _ := registryimage.FromArtifact(a,
+			registryimage.WithID(s.id),
+			registryimage.WithProvider("ionoscloud"),
+			registryimage.WithRegion(a.location),
+			registryimage.SetLabels(map[string]interface{}{"volume": s.volume}),
+		)
+		images = append(images, dataImg)
+	}
+	return images
+}
+
 func (a *Artifact) Destroy() error {
+	if a.snapshotId == "" {
+		return nil
+	}
+	if err := a.deleteSnapshot(a.snapshotId, a.snapshotName); err != nil {
+		return err
+	}
+	for _, s := range a.dataSnapshots {
+		if err := a.deleteSnapshot(s.id, s.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Artifact) deleteSnapshot(id, name string) error {
+	log.Printf("Destroying snapshot %s (%s)", name, id)
+	return deleteSnapshot(context.Background(), a.client, id)
+}
+
+// deleteSnapshot deletes the snapshot id and waits for the deletion to
+// finish.
+func deleteSnapshot(ctx context.Context, client *ionoscloud.APIClient, id string) error {
+	apiResponse, err := client.SnapshotsApi.SnapshotsDelete(ctx, id).Execute()
+	if err != nil {
+		return fmt.Errorf("error deleting snapshot %s: %w", id, err)
+	}
+
+	requestPath := getRequestPath(apiResponse)
+	if requestPath == "" {
+		return nil
+	}
+	if _, err := client.WaitForRequest(ctx, requestPath); err != nil {
+		return fmt.Errorf("error while waiting for snapshot %s to be deleted: %w", id, err)
+	}
 	return nil
 }
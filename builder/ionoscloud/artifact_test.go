@@ -4,9 +4,13 @@
 package ionoscloud
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	registryimage "github.com/hashicorp/packer-plugin-sdk/packer/registry/image"
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
 )
 
 func TestArtifact_Impl(t *testing.T) {
@@ -18,7 +22,7 @@ func TestArtifact_Impl(t *testing.T) {
 
 func TestArtifactString(t *testing.T) {
 	generatedData := make(map[string]interface{})
-	a := &Artifact{"packer-foobar", generatedData}
+	a := &Artifact{snapshotName: "packer-foobar", StateData: generatedData}
 	expected := "A snapshot was created: 'packer-foobar'"
 
 	if a.String() != expected {
@@ -26,6 +30,19 @@ func TestArtifactString(t *testing.T) {
 	}
 }
 
+func TestArtifactId(t *testing.T) {
+	a := &Artifact{
+		snapshotId:   "b8a6e8a2-2c5c-4b0c-9a1a-5b8e3f1f3c2d",
+		snapshotName: "packer-foobar",
+		location:     "de/fra",
+	}
+	expected := "de/fra:b8a6e8a2-2c5c-4b0c-9a1a-5b8e3f1f3c2d"
+
+	if a.Id() != expected {
+		t.Fatalf("artifact id should match: %v, got %v", expected, a.Id())
+	}
+}
+
 func TestArtifactState_StateData(t *testing.T) {
 	expectedData := "this is the data"
 	artifact := &Artifact{
@@ -51,3 +68,122 @@ func TestArtifactState_StateData(t *testing.T) {
 		t.Fatalf("Bad: State should be nil for nil StateData")
 	}
 }
+
+func TestArtifactState_hcpPackerRegistryMetadata(t *testing.T) {
+	artifact := &Artifact{
+		snapshotId:    "snapshot-id",
+		location:      "de/fra",
+		sourceImageId: "image-id",
+		labels: map[string]string{
+			"source_image_name": "Ubuntu-22.04",
+			"disk_type":         "SSD",
+			"cores":             "4",
+			"ram":               "2048",
+		},
+	}
+
+	img, ok := artifact.State(registryimage.ArtifactStateURI).(*registryimage.Image)
+	if !ok {
+		t.Fatalf("Bad: HCP Packer registry image data was nil")
+	}
+	if img.ImageID != "snapshot-id" || img.ProviderName != "ionoscloud" || img.ProviderRegion != "de/fra" {
+		t.Fatalf("Bad: HCP Packer registry image data was %#v", img)
+	}
+	if img.SourceImageID != "image-id" {
+		t.Fatalf("Bad: source image id was %s", img.SourceImageID)
+	}
+	if img.Labels["disk_type"] != "SSD" || img.Labels["ram"] != "2048" {
+		t.Fatalf("Bad: labels were %#v", img.Labels)
+	}
+}
+
+func TestArtifactDestroy(t *testing.T) {
+	deleted := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = r.URL.Path
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	artifact := &Artifact{
+		snapshotId: "snapshot-id",
+		location:   "de/fra",
+		client:     ionoscloud.NewAPIClient(ionoscloud.NewConfiguration("", "", "token", srv.URL)),
+	}
+	if err := artifact.Destroy(); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if deleted != "/cloudapi/v6/snapshots/snapshot-id" {
+		t.Fatalf("bad delete request: %q", deleted)
+	}
+}
+
+func TestArtifact_DataSnapshots(t *testing.T) {
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	artifact := &Artifact{
+		snapshotId:   "boot-id",
+		snapshotName: "packer-db",
+		location:     "de/fra",
+		dataSnapshots: []artifactSnapshot{
+			{id: "data-id", name: "packer-db-data", volume: "data"},
+		},
+		client: ionoscloud.NewAPIClient(ionoscloud.NewConfiguration("", "", "token", srv.URL)),
+	}
+
+	if id := artifact.Id(); id != "de/fra:boot-id,de/fra:data-id" {
+		t.Fatalf("bad artifact id: %s", id)
+	}
+	if s := artifact.String(); s != "Snapshots were created: 'packer-db', 'packer-db-data' (data)" {
+		t.Fatalf("bad artifact string: %s", s)
+	}
+
+	images, ok := artifact.State(registryimage.ArtifactStateURI).([]*registryimage.Image)
+	if !ok || len(images) != 2 {
+		t.Fatalf("Bad: HCP Packer registry should get one image per snapshot: %#v", images)
+	}
+	if images[1].ImageID != "data-id" || images[1].Labels["volume"] != "data" {
+		t.Fatalf("Bad: data snapshot image was %#v", images[1])
+	}
+
+	if err := artifact.Destroy(); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(deleted) != 2 || deleted[1] != "/cloudapi/v6/snapshots/data-id" {
+		t.Fatalf("all snapshots should be deleted: %v", deleted)
+	}
+}
+
+func TestArtifact_NoSnapshot(t *testing.T) {
+	artifact := &Artifact{
+		location:      "de/fra",
+		sourceImageId: "image-id",
+		StateData:     map[string]interface{}{"generated_data": map[string]interface{}{"ServerID": "server-id"}},
+	}
+
+	if id := artifact.Id(); id != "" {
+		t.Fatalf("artifact id should be empty: %s", id)
+	}
+	if s := artifact.String(); s != "No snapshot was created" {
+		t.Fatalf("bad artifact string: %s", s)
+	}
+	if img := artifact.State(registryimage.ArtifactStateURI); img != nil {
+		t.Fatalf("Bad: nothing should be stored on the HCP Packer registry: %#v", img)
+	}
+	if artifact.State("generated_data") == nil {
+		t.Fatal("Bad: generated data should be kept")
+	}
+	// the artifact has no client, Destroy must not call the API
+	if err := artifact.Destroy(); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
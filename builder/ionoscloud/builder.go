@@ -5,13 +5,16 @@ package ionoscloud
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
 	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
 )
 
@@ -30,7 +33,20 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		return nil, warnings, errs
 	}
 
-	return nil, warnings, nil
+	generatedData := []string{
+		"DatacenterID",
+		"ServerID",
+		"VolumeID",
+		"SourceImageID",
+		"SourceImageName",
+		"SourceType",
+		"SnapshotID",
+		"DataSnapshotIDs",
+		"ServerIP",
+		"IPBlockID",
+		"ReservedIP",
+	}
+	return generatedData, warnings, nil
 }
 
 func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
@@ -40,16 +56,21 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	state.Put("hook", hook)
 	state.Put("ui", ui)
 
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+
 	client, err := b.newAPIClient(state)
 	if err != nil {
 		return nil, err
 	}
 	steps := []multistep.Step{
+		newStepPreflight(client, generatedData),
+		newStepCheckQuota(client),
 		&StepCreateSSHKey{
 			Debug:        b.config.PackerDebug,
 			DebugKeyPath: fmt.Sprintf("ionos_%s", b.config.SnapshotName),
 		},
-		newStepCreateServer(client),
+		newStepReserveIp(client, generatedData),
+		newStepCreateServer(client, generatedData),
 		&communicator.StepConnect{
 			Config:    &b.config.Comm,
 			Host:      communicator.CommHost(b.config.Comm.Host(), "server_ip"),
@@ -59,7 +80,12 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.Comm,
 		},
-		newStepTakeSnapshot(client),
+	}
+	if !b.config.SkipCreateSnapshot {
+		steps = append(steps,
+			newStepShutdown(client),
+			newStepTakeSnapshot(client, generatedData),
+		)
 	}
 
 	config := state.Get("config").(*Config)
@@ -71,18 +97,52 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		return nil, rawErr.(error)
 	}
 
+	// skip_if_exists halts the build without an error
+	if _, ok := state.GetOk("snapshot_exists"); ok {
+		return nil, nil
+	}
+
+	if _, ok := state.GetOk(multistep.StateCancelled); ok {
+		return nil, errors.New("build was cancelled")
+	}
+
+	if _, ok := state.GetOk(multistep.StateHalted); ok {
+		return nil, errors.New("build was halted")
+	}
+
 	artifact := &Artifact{
-		snapshotData: config.SnapshotName,
-		StateData:    map[string]interface{}{"generated_data": state.Get("generated_data")},
+		location: config.Region,
+		labels: map[string]string{
+			"disk_type":   config.DiskType,
+			"server_type": config.ServerType,
+		},
+		StateData: map[string]interface{}{"generated_data": state.Get("generated_data")},
+		client:    client,
+	}
+	if sourceImageId, ok := state.GetOk("source_image_id"); ok {
+		artifact.sourceImageId = sourceImageId.(string)
+	}
+	if sourceImageName, ok := state.GetOk("source_image_name"); ok {
+		artifact.labels["source_image_name"] = sourceImageName.(string)
+	}
+	// without a snapshot the artifact only carries the build details
+	if snapshotId, ok := state.GetOk("snapshot_id"); ok {
+		artifact.snapshotId = snapshotId.(string)
+		artifact.snapshotName = config.SnapshotName
+	}
+	if config.ServerType == serverTypeCube {
+		artifact.labels["template"] = config.TemplateName + config.TemplateUuid
+	} else {
+		artifact.labels["cores"] = strconv.Itoa(int(config.Cores))
+		artifact.labels["ram"] = strconv.Itoa(int(config.Ram))
+	}
+	if dataSnapshots, ok := state.GetOk("data_snapshots"); ok {
+		artifact.dataSnapshots = dataSnapshots.([]artifactSnapshot)
 	}
 	return artifact, nil
 }
 
 func (b *Builder) newAPIClient(state multistep.StateBag) (*ionoscloud.APIClient, error) {
 	c := state.Get("config").(*Config)
-	cfg := ionoscloud.NewConfiguration(c.IonosUsername, c.IonosPassword, "", "")
-	cfg.SetDepth(5)
-
-	// new apiclient for ionoscloud
-	return ionoscloud.NewAPIClient(cfg), nil
+	return c.AccessConfig.Client(), nil
 }
@@ -31,8 +31,12 @@ func TestBuilderAcc_basic(t *testing.T) {
 }
 
 func testAccPreCheck() error {
+	if v := os.Getenv("IONOS_TOKEN"); v != "" {
+		return nil
+	}
+
 	if v := os.Getenv("IONOS_USERNAME"); v == "" {
-		return fmt.Errorf("IONOS_USERNAME must be set for acceptance tests")
+		return fmt.Errorf("IONOS_TOKEN or IONOS_USERNAME must be set for acceptance tests")
 	}
 
 	if v := os.Getenv("IONOS_PASSWORD"); v == "" {
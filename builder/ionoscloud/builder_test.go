@@ -5,7 +5,11 @@ package ionoscloud
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
@@ -16,6 +20,8 @@ func testConfig() map[string]interface{} {
 		"password":      "password",
 		"username":      "username",
 		"snapshot_name": "packer",
+		"ssh_username":  "root",
+		"ssh_password":  "password",
 		"type":          "ionoscloud",
 	}
 }
@@ -57,3 +63,639 @@ func TestBuilderPrepare_InvalidKey(t *testing.T) {
 		t.Fatal("should have error")
 	}
 }
+
+func TestBuilderPrepare_Retries(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	_, _, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.Retries != 3 {
+		t.Fatalf("bad default retries: %d", b.config.Retries)
+	}
+	if b.config.RetryWaitMin != time.Second || b.config.RetryWaitMax != 30*time.Second {
+		t.Fatalf("bad default retry wait: %s - %s", b.config.RetryWaitMin, b.config.RetryWaitMax)
+	}
+
+	b = Builder{}
+	config = testConfig()
+	config["retries"] = -1
+	if _, _, err = b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.Retries != -1 {
+		t.Fatalf("retries should stay disabled: %d", b.config.Retries)
+	}
+
+	b = Builder{}
+	config["retries"] = -2
+	if _, _, err = b.Prepare(config); err == nil {
+		t.Fatal("should have error for retries below -1")
+	}
+
+	b = Builder{}
+	config = testConfig()
+	config["retry_wait_min"] = "10s"
+	config["retry_wait_max"] = "5s"
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_Token(t *testing.T) {
+	t.Setenv("IONOS_TOKEN", "env-token")
+	var b Builder
+	config := testConfig()
+	delete(config, "username")
+	delete(config, "password")
+
+	_, warnings, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if b.config.IonosToken != "env-token" {
+		t.Fatalf("token should be read from the environment, got %q", b.config.IonosToken)
+	}
+
+	b = Builder{}
+	config["token"] = "config-token"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.IonosToken != "config-token" {
+		t.Fatalf("config token should take precedence, got %q", b.config.IonosToken)
+	}
+}
+
+func TestBuilderPrepare_UsernamePassword(t *testing.T) {
+	t.Setenv("IONOS_TOKEN", "")
+	t.Setenv("IONOS_USERNAME", "env-user")
+	t.Setenv("IONOS_PASSWORD", "env-password")
+	var b Builder
+	config := testConfig()
+	delete(config, "password")
+
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.IonosUsername != "username" || b.config.IonosPassword != "env-password" {
+		t.Fatalf("the config username should be combined with the environment password, got %q %q",
+			b.config.IonosUsername, b.config.IonosPassword)
+	}
+
+	// a token wins over a username and password
+	t.Setenv("IONOS_TOKEN", "env-token")
+	b = Builder{}
+	delete(config, "username")
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.IonosToken != "env-token" || b.config.IonosUsername != "" {
+		t.Fatalf("the token should be used, got %q %q", b.config.IonosToken, b.config.IonosUsername)
+	}
+}
+
+func TestBuilderPrepare_Profile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	content := `
+[default]
+username = default-user
+password = default-password
+
+[ci]
+token = profile-token
+url   = https://api.example.com
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("IONOS_TOKEN", "")
+	t.Setenv("IONOS_API_URL", "")
+	t.Setenv("IONOS_USERNAME", "")
+	t.Setenv("IONOS_PASSWORD", "")
+
+	var b Builder
+	config := testConfig()
+	delete(config, "username")
+	delete(config, "password")
+	config["credentials_file"] = path
+	config["profile"] = "ci"
+
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.IonosToken != "profile-token" {
+		t.Fatalf("bad token: %q", b.config.IonosToken)
+	}
+	if b.config.IonosApiUrl != "https://api.example.com" {
+		t.Fatalf("bad url: %q", b.config.IonosApiUrl)
+	}
+
+	// values from the environment take precedence over the file
+	t.Setenv("IONOS_TOKEN", "env-token")
+	b = Builder{}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.IonosToken != "env-token" {
+		t.Fatalf("bad token: %q", b.config.IonosToken)
+	}
+
+	// the file is not read when the config has complete credentials
+	t.Setenv("IONOS_TOKEN", "")
+	b = Builder{}
+	config["profile"] = "ci"
+	config["username"] = "config-user"
+	config["password"] = "config-password"
+	_, warnings, err := b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.IonosToken != "" || b.config.IonosUsername != "config-user" {
+		t.Fatalf("the profile should not be used: token %q, username %q", b.config.IonosToken, b.config.IonosUsername)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("should warn that the profile is not used: %#v", warnings)
+	}
+
+	b = Builder{}
+	delete(config, "username")
+	delete(config, "password")
+	config["profile"] = "missing"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for unknown profile")
+	}
+}
+
+func TestBuilderPrepare_GeneratedData(t *testing.T) {
+	var b Builder
+	generatedData, _, err := b.Prepare(testConfig())
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	expected := []string{"DatacenterID", "ServerID", "VolumeID", "SourceImageID", "SourceImageName", "SnapshotID", "ServerIP", "IPBlockID", "ReservedIP"}
+	for _, name := range expected {
+		found := false
+		for _, v := range generatedData {
+			if v == name {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("generated data should contain %s: %#v", name, generatedData)
+		}
+	}
+}
+
+func TestBuilderPrepare_ImageAlias(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	delete(config, "image")
+	config["image_alias"] = "ubuntu:latest"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	b = Builder{}
+	config["image"] = "Ubuntu-22.04"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("image and image_alias should be mutually exclusive")
+	}
+}
+
+func TestBuilderPrepare_SourceSnapshot(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	delete(config, "image")
+	config["source_snapshot"] = "base-hardened"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	b = Builder{}
+	config["image_alias"] = "ubuntu:latest"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("source_snapshot and image_alias should be mutually exclusive")
+	}
+
+	b = Builder{}
+	delete(config, "image_alias")
+	config["image_visibility"] = "internal"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for unknown image_visibility")
+	}
+}
+
+func TestBuilderPrepare_ImageFilter(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	delete(config, "image")
+	config["image_filter"] = map[string]interface{}{
+		"name":        "^ubuntu-22",
+		"most_recent": true,
+	}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ImageFilter.Visibility != imageVisibilityPublic {
+		t.Fatalf("bad default visibility: %s", b.config.ImageFilter.Visibility)
+	}
+
+	b = Builder{}
+	config["image_filter"] = map[string]interface{}{
+		"name": "^ubuntu-(",
+	}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for invalid regular expression")
+	}
+
+	b = Builder{}
+	config["image"] = "Ubuntu-22.04"
+	config["image_filter"] = map[string]interface{}{
+		"name": "^ubuntu-22",
+	}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("image and image_filter should be mutually exclusive")
+	}
+}
+
+func TestBuilderPrepare_Datacenter(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["datacenter_name"] = "packer-builds"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	b = Builder{}
+	config["datacenter_id"] = "b8a6e8a2-2c5c-4b0c-9a1a-5b8e3f1f3c2d"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("datacenter_id and datacenter_name should be mutually exclusive")
+	}
+}
+
+func TestBuilderPrepare_Lan(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["lan_id"] = "2"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("lan_id should require an existing datacenter")
+	}
+
+	b = Builder{}
+	config["datacenter_name"] = "packer-builds"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	b = Builder{}
+	config["lan_name"] = "builds"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("lan_id and lan_name should be mutually exclusive")
+	}
+
+	b = Builder{}
+	delete(config, "lan_name")
+	config["lan_id"] = "builds"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for non-numeric lan_id")
+	}
+}
+
+func TestBuilderPrepare_ReserveIp(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["reserve_ip"] = true
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	b = Builder{}
+	config["ip_block_id"] = "b8a6e8a2-2c5c-4b0c-9a1a-5b8e3f1f3c2d"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("reserve_ip and ip_block_id should be mutually exclusive")
+	}
+
+	b = Builder{}
+	delete(config, "reserve_ip")
+	config["datacenter_name"] = "packer-builds"
+	config["nic"] = []map[string]interface{}{
+		{"name": "public"},
+		{"name": "private", "lan_name": "backend"},
+	}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	b = Builder{}
+	config["communicator_nic"] = "private"
+	_, _, err := b.Prepare(config)
+	if err == nil || !strings.Contains(err.Error(), "is on an existing LAN") {
+		t.Fatalf("ip_block_id should require the public LAN of the build: %v", err)
+	}
+
+	b = Builder{}
+	delete(config, "nic")
+	delete(config, "communicator_nic")
+	config["lan_id"] = "2"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("ip_block_id should not be used with lan_id")
+	}
+}
+
+func TestBuilderPrepare_Firewall(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["firewall_source_cidrs"] = []string{"192.0.2.0/24"}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("firewall_source_cidrs should require firewall_active")
+	}
+
+	b = Builder{}
+	config = testConfig()
+	config["firewall_active"] = true
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("firewall_active should require a source for the communicator")
+	}
+
+	b = Builder{}
+	config["firewall_source_public_ip"] = true
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	delete(config, "firewall_source_public_ip")
+
+	b = Builder{}
+	config["firewall_source_cidrs"] = []string{"192.0.2.0/33"}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for invalid CIDR")
+	}
+
+	b = Builder{}
+	config["firewall_source_cidrs"] = []string{"192.0.2.0/24"}
+	config["firewall_rule"] = []map[string]interface{}{
+		{"protocol": "icmp", "port_range_start": 80},
+	}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for ICMP port range")
+	}
+}
+
+func TestBuilderPrepare_Nics(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(b.config.Nics) != 1 || b.config.CommunicatorNic != "packer" || !*b.config.Nics[0].Dhcp {
+		t.Fatalf("bad default NIC: %#v", b.config.Nics)
+	}
+
+	b = Builder{}
+	config["datacenter_name"] = "packer-builds"
+	config["nic"] = []map[string]interface{}{
+		{"name": "public"},
+		{"name": "internal", "lan_name": "artifacts", "dhcp": false, "ips": []string{"10.7.0.5"}},
+	}
+	config["communicator_nic"] = "internal"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	b = Builder{}
+	config["communicator_nic"] = "other"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for unknown communicator_nic")
+	}
+
+	b = Builder{}
+	delete(config, "communicator_nic")
+	config["nic"] = []map[string]interface{}{
+		{"name": "public"},
+		{"name": "public", "ips": []string{"10.7.0.300"}},
+	}
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	for _, msg := range []string{"is used by another NIC", "is not an IP address"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("error should contain %q: %s", msg, err)
+		}
+	}
+}
+
+func TestBuilderPrepare_Volumes(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["volume"] = []map[string]interface{}{
+		{"size": 100, "type": "ssd", "snapshot": true},
+		{"name": "scratch", "size": 20},
+		{"name": "fast", "size": 10, "type": "ssd standard"},
+	}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.Volumes[2].Type != "SSD Standard" {
+		t.Fatalf("the volume type should use the API spelling: %s", b.config.Volumes[2].Type)
+	}
+	v := b.config.Volumes[0]
+	if v.Name != "data-0" || v.Type != "SSD" || v.SnapshotName != "packer-data-0" {
+		t.Fatalf("bad volume defaults: %#v", v)
+	}
+	if b.config.Volumes[1].SnapshotName != "" {
+		t.Fatal("volumes should only be snapshotted on request")
+	}
+
+	b = Builder{}
+	config["volume"] = []map[string]interface{}{
+		{"name": "packer", "size": 0, "image": "ubuntu"},
+	}
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	for _, msg := range []string{"is used by another volume", "'size' is required", "must be the UUID"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("error should contain %q: %s", msg, err)
+		}
+	}
+}
+
+func TestBuilderPrepare_ServerType(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["server_type"] = "cube"
+	config["template_name"] = "CUBES XS"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ServerType != "CUBE" || b.config.DiskType != "DAS" {
+		t.Fatalf("bad CUBE defaults: %s, %s", b.config.ServerType, b.config.DiskType)
+	}
+	if b.config.Cores != 0 || b.config.Ram != 0 || b.config.DiskSize != 0 {
+		t.Fatal("a CUBE server should take cores, RAM and disk size from the template")
+	}
+
+	b = Builder{}
+	config["cores"] = 2
+	config["template_uuid"] = "not-a-uuid"
+	config["cpu_family"] = "INTEL_SKYLAKE"
+	config["availability_zone"] = "ZONE_3"
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	for _, msg := range []string{"one of 'template_uuid' or 'template_name'", "is not a UUID", "'cores' and 'ram' can not be set", "'cpu_family' can only be set", "'availability_zone' must be"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("error should contain %q: %s", msg, err)
+		}
+	}
+
+	b = Builder{}
+	config = testConfig()
+	config["template_name"] = "CUBES XS"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("templates should require a CUBE server")
+	}
+}
+
+func TestBuilderPrepare_RamMultiple(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["ram"] = 1000
+	_, _, err := b.Prepare(config)
+	if err == nil || !strings.Contains(err.Error(), "multiple of 256") {
+		t.Fatalf("should have error for ram: %v", err)
+	}
+}
+
+func TestBuilderPrepare_SnapshotProperties(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["snapshot_licence_type"] = "linux"
+	config["snapshot_cpu_hot_plug"] = true
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SnapshotLicenceType != "LINUX" {
+		t.Fatalf("bad licence type: %s", b.config.SnapshotLicenceType)
+	}
+
+	b = Builder{}
+	config["snapshot_licence_type"] = "BSD"
+	_, _, err := b.Prepare(config)
+	if err == nil || !strings.Contains(err.Error(), "unknown 'snapshot_licence_type'") {
+		t.Fatalf("should have error for the licence type: %v", err)
+	}
+
+	b = Builder{}
+	delete(config, "snapshot_licence_type")
+	config["snapshot_description"] = "{{ .SnapshotName"
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error for an invalid description template")
+	}
+}
+
+func TestBuilderPrepare_Labels(t *testing.T) {
+	t.Setenv("PACKER_RUN_UUID", "run-uuid")
+
+	var b Builder
+	config := testConfig()
+	config["packer_build_name"] = "ionoscloud.ubuntu"
+	config["snapshot_labels"] = map[string]string{"team": "infra", "packer-build-name": "ubuntu"}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	snapshot := b.config.SnapshotLabels
+	if snapshot["team"] != "infra" || snapshot["packer-build-name"] != "ubuntu" || snapshot["packer-run-uuid"] != "run-uuid" {
+		t.Fatalf("bad snapshot labels: %v", snapshot)
+	}
+	run := b.config.RunLabels
+	if run["packer-build-name"] != "ionoscloud.ubuntu" || run["packer-run-uuid"] != "run-uuid" {
+		t.Fatalf("bad run labels: %v", run)
+	}
+}
+
+func TestBuilderPrepare_SnapshotExists(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["volume"] = []map[string]interface{}{
+		{"name": "data", "size": 10, "snapshot": true},
+	}
+	config["force_delete_snapshot"] = true
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if names := b.config.snapshotNames(); len(names) != 2 || names[0] != "packer" || names[1] != "packer-data" {
+		t.Fatalf("bad snapshot names: %v", names)
+	}
+
+	b = Builder{}
+	config["skip_if_exists"] = true
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_Shutdown(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ShutdownBehavior != "none" || b.config.ShutdownTimeout != 5*time.Minute {
+		t.Fatalf("bad shutdown defaults: %s, %s", b.config.ShutdownBehavior, b.config.ShutdownTimeout)
+	}
+
+	b = Builder{}
+	config["shutdown_behavior"] = "command"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ShutdownCommand != "shutdown -P now" {
+		t.Fatalf("bad shutdown command: %s", b.config.ShutdownCommand)
+	}
+
+	b = Builder{}
+	config["shutdown_behavior"] = "stop"
+	config["shutdown_command"] = "poweroff"
+	config["shutdown_timeout"] = "-1m"
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	for _, msg := range []string{"'shutdown_command' requires", "'shutdown_timeout' must not be negative"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("error should contain %q: %s", msg, err)
+		}
+	}
+}
+
+func TestBuilderPrepare_DiskType(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["disk_type"] = "ssd premium"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.DiskType != "SSD Premium" {
+		t.Fatalf("bad disk type: %s", b.config.DiskType)
+	}
+
+	for _, diskType := range []string{"SDD", "DAS"} {
+		b = Builder{}
+		config["disk_type"] = diskType
+		_, _, err := b.Prepare(config)
+		if err == nil || !strings.Contains(err.Error(), "unknown 'disk_type'") {
+			t.Fatalf("should have error for disk type %s: %v", diskType, err)
+		}
+	}
+}
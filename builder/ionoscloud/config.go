@@ -1,19 +1,26 @@
 // Copyright (c) HashiCorp, Inc.
 // SPDX-License-Identifier: MPL-2.0
 
-//go:generate packer-sdc mapstructure-to-hcl2 -type Config
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,ImageFilter,FirewallRule,Nic,Volume
 
 package ionoscloud
 
 import (
 	"errors"
+	"fmt"
+	"net"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-sdk/uuid"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -21,9 +28,7 @@ type Config struct {
 	common.PackerConfig `mapstructure:",squash"`
 	Comm                communicator.Config `mapstructure:",squash"`
 
-	IonosUsername string `mapstructure:"username"`
-	IonosPassword string `mapstructure:"password"`
-	IonosApiUrl   string `mapstructure:"url"`
+	AccessConfig `mapstructure:",squash"`
 
 	Region       string  `mapstructure:"location"`
 	Image        string  `mapstructure:"image"`
@@ -32,8 +37,438 @@ type Config struct {
 	DiskType     string  `mapstructure:"disk_type"`
 	Cores        int32   `mapstructure:"cores"`
 	Ram          int32   `mapstructure:"ram"`
-	Retries      int     `mapstructure:"retries"`
-	ctx          interpolate.Context
+
+	ServerType       string `mapstructure:"server_type"`
+	TemplateUuid     string `mapstructure:"template_uuid"`
+	TemplateName     string `mapstructure:"template_name"`
+	CpuFamily        string `mapstructure:"cpu_family"`
+	AvailabilityZone string `mapstructure:"availability_zone"`
+
+	ImageAlias      string      `mapstructure:"image_alias"`
+	ImageMatch      string      `mapstructure:"image_match"`
+	ImageMostRecent bool        `mapstructure:"image_most_recent"`
+	ImageVisibility string      `mapstructure:"image_visibility"`
+	SourceSnapshot  string      `mapstructure:"source_snapshot"`
+	ImageFilter     ImageFilter `mapstructure:"image_filter"`
+
+	DatacenterId   string `mapstructure:"datacenter_id"`
+	DatacenterName string `mapstructure:"datacenter_name"`
+	LanId          string `mapstructure:"lan_id"`
+	LanName        string `mapstructure:"lan_name"`
+
+	ReserveIp bool   `mapstructure:"reserve_ip"`
+	IpBlockId string `mapstructure:"ip_block_id"`
+
+	FirewallActive         bool           `mapstructure:"firewall_active"`
+	FirewallRules          []FirewallRule `mapstructure:"firewall_rule"`
+	FirewallSourceCidrs    []string       `mapstructure:"firewall_source_cidrs"`
+	FirewallSourcePublicIp bool           `mapstructure:"firewall_source_public_ip"`
+
+	Nics            []Nic  `mapstructure:"nic"`
+	CommunicatorNic string `mapstructure:"communicator_nic"`
+
+	Volumes []Volume `mapstructure:"volume"`
+
+	SnapshotDescription         string `mapstructure:"snapshot_description"`
+	SnapshotLicenceType         string `mapstructure:"snapshot_licence_type"`
+	SnapshotSecAuthProtection   *bool  `mapstructure:"snapshot_sec_auth_protection"`
+	SnapshotCpuHotPlug          *bool  `mapstructure:"snapshot_cpu_hot_plug"`
+	SnapshotCpuHotUnplug        *bool  `mapstructure:"snapshot_cpu_hot_unplug"`
+	SnapshotRamHotPlug          *bool  `mapstructure:"snapshot_ram_hot_plug"`
+	SnapshotRamHotUnplug        *bool  `mapstructure:"snapshot_ram_hot_unplug"`
+	SnapshotNicHotPlug          *bool  `mapstructure:"snapshot_nic_hot_plug"`
+	SnapshotNicHotUnplug        *bool  `mapstructure:"snapshot_nic_hot_unplug"`
+	SnapshotDiscVirtioHotPlug   *bool  `mapstructure:"snapshot_disc_virtio_hot_plug"`
+	SnapshotDiscVirtioHotUnplug *bool  `mapstructure:"snapshot_disc_virtio_hot_unplug"`
+	SnapshotDiscScsiHotPlug     *bool  `mapstructure:"snapshot_disc_scsi_hot_plug"`
+	SnapshotDiscScsiHotUnplug   *bool  `mapstructure:"snapshot_disc_scsi_hot_unplug"`
+
+	ForceDeleteSnapshot bool `mapstructure:"force_delete_snapshot"`
+	SkipIfExists        bool `mapstructure:"skip_if_exists"`
+	SkipCreateSnapshot  bool `mapstructure:"skip_create_snapshot"`
+
+	ShutdownBehavior string        `mapstructure:"shutdown_behavior"`
+	ShutdownCommand  string        `mapstructure:"shutdown_command"`
+	ShutdownTimeout  time.Duration `mapstructure:"shutdown_timeout"`
+
+	SnapshotLabels map[string]string `mapstructure:"snapshot_labels"`
+	RunLabels      map[string]string `mapstructure:"run_labels"`
+
+	CheckQuota       bool          `mapstructure:"check_quota"`
+	QuotaWaitTimeout time.Duration `mapstructure:"quota_wait_timeout"`
+
+	ctx interpolate.Context
+}
+
+// ImageFilter selects the source image by its properties rather than by a
+// single name.
+type ImageFilter struct {
+	// Name is a regular expression matched against the image names.
+	Name        string            `mapstructure:"name"`
+	LicenceType string            `mapstructure:"licence_type"`
+	ImageType   string            `mapstructure:"image_type"`
+	Location    string            `mapstructure:"location"`
+	Visibility  string            `mapstructure:"visibility"`
+	CloudInit   string            `mapstructure:"cloud_init"`
+	Labels      map[string]string `mapstructure:"labels"`
+	MostRecent  bool              `mapstructure:"most_recent"`
+}
+
+// SnapshotFilter selects an existing snapshot by name or by regular
+// expression.
+type SnapshotFilter struct {
+	Name       string            `mapstructure:"name"`
+	NameRegex  string            `mapstructure:"name_regex"`
+	Location   string            `mapstructure:"location"`
+	Labels     map[string]string `mapstructure:"labels"`
+	MostRecent bool              `mapstructure:"most_recent"`
+}
+
+// FirewallRule is an additional rule of the build server NIC firewall.
+type FirewallRule struct {
+	Name           string `mapstructure:"name"`
+	Protocol       string `mapstructure:"protocol"`
+	SourceIp       string `mapstructure:"source_ip"`
+	TargetIp       string `mapstructure:"target_ip"`
+	PortRangeStart int32  `mapstructure:"port_range_start"`
+	PortRangeEnd   int32  `mapstructure:"port_range_end"`
+	Type           string `mapstructure:"type"`
+}
+
+// Nic is a network interface of the build server. A NIC without a LAN
+// reference is connected to the build LAN.
+type Nic struct {
+	Name    string   `mapstructure:"name"`
+	LanId   string   `mapstructure:"lan_id"`
+	LanName string   `mapstructure:"lan_name"`
+	Dhcp    *bool    `mapstructure:"dhcp"`
+	Ips     []string `mapstructure:"ips"`
+	Dhcpv6  *bool    `mapstructure:"dhcpv6"`
+	Ipv6Ips []string `mapstructure:"ipv6_ips"`
+	// FirewallActive defaults to the firewall_active option of the build.
+	FirewallActive *bool `mapstructure:"firewall_active"`
+}
+
+const (
+	serverTypeEnterprise = "ENTERPRISE"
+	serverTypeCube       = "CUBE"
+	serverTypeVcpu       = "VCPU"
+)
+
+// Volume is an additional data volume of the build server.
+type Volume struct {
+	Name string  `mapstructure:"name"`
+	Size float32 `mapstructure:"size"`
+	Type string  `mapstructure:"type"`
+	Bus  string  `mapstructure:"bus"`
+	// Image is the UUID of an image or snapshot the volume is created from.
+	Image        string `mapstructure:"image"`
+	Snapshot     bool   `mapstructure:"snapshot"`
+	SnapshotName string `mapstructure:"snapshot_name"`
+}
+
+func (f *ImageFilter) empty() bool {
+	return f.Name == "" && f.LicenceType == "" && f.ImageType == "" && f.Location == "" &&
+		f.Visibility == "" && f.CloudInit == "" && len(f.Labels) == 0 && !f.MostRecent
+}
+
+// Prepare sets the defaults of a non-empty filter and validates it.
+func (f *ImageFilter) Prepare() []error {
+	var errs []error
+	if f.Visibility == "" {
+		f.Visibility = imageVisibilityPublic
+	}
+	if !validImageVisibility(f.Visibility) {
+		errs = append(errs, fmt.Errorf("'visibility' must be one of %s, %s or %s",
+			imageVisibilityPublic, imageVisibilityPrivate, imageVisibilityAny))
+	}
+	if _, err := regexp.Compile(f.Name); err != nil {
+		errs = append(errs, fmt.Errorf("'name' is not a valid regular expression: %w", err))
+	}
+	switch strings.ToUpper(f.CloudInit) {
+	case "", "NONE", "V1":
+	default:
+		errs = append(errs, errors.New("'cloud_init' must be one of NONE or V1"))
+	}
+	return errs
+}
+
+// Prepare validates the filter.
+func (f *SnapshotFilter) Prepare() []error {
+	var errs []error
+	if f.Name != "" && f.NameRegex != "" {
+		errs = append(errs, errors.New("only one of 'name' or 'name_regex' can be set"))
+	}
+	if _, err := regexp.Compile(f.NameRegex); err != nil {
+		errs = append(errs, fmt.Errorf("'name_regex' is not a valid regular expression: %w", err))
+	}
+	if f.Location == "" {
+		errs = append(errs, errors.New("'location' is required"))
+	}
+	return errs
+}
+
+// Prepare sets the defaults of the rule and validates it.
+func (r *FirewallRule) Prepare() []error {
+	var errs []error
+	r.Protocol = strings.ToUpper(r.Protocol)
+	switch r.Protocol {
+	case "TCP", "UDP", "ICMP", "ICMPV6", "GRE", "VRRP", "ESP", "AH", "ANY":
+	default:
+		errs = append(errs, fmt.Errorf("unknown 'protocol' %q", r.Protocol))
+	}
+	if r.Type == "" {
+		r.Type = "INGRESS"
+	}
+	r.Type = strings.ToUpper(r.Type)
+	if r.Type != "INGRESS" && r.Type != "EGRESS" {
+		errs = append(errs, errors.New("'type' must be one of INGRESS or EGRESS"))
+	}
+	if r.PortRangeStart != 0 || r.PortRangeEnd != 0 {
+		if r.Protocol != "TCP" && r.Protocol != "UDP" {
+			errs = append(errs, errors.New("port ranges are only supported for TCP and UDP"))
+		}
+		if r.PortRangeEnd == 0 {
+			r.PortRangeEnd = r.PortRangeStart
+		}
+		if r.PortRangeStart < 1 || r.PortRangeStart > r.PortRangeEnd || r.PortRangeEnd > 65534 {
+			errs = append(errs, fmt.Errorf("invalid port range %d-%d", r.PortRangeStart, r.PortRangeEnd))
+		}
+	}
+	for _, ip := range []string{r.SourceIp, r.TargetIp} {
+		if ip != "" && !validIpOrCidr(ip) {
+			errs = append(errs, fmt.Errorf("%q is not an IP address or CIDR block", ip))
+		}
+	}
+	return errs
+}
+
+func validIpOrCidr(s string) bool {
+	if _, _, err := net.ParseCIDR(s); err == nil {
+		return true
+	}
+	return net.ParseIP(s) != nil
+}
+
+// prepare validates the LAN and address settings of the NIC.
+func (n *Nic) prepare(c *Config) []error {
+	var errs []error
+	if n.LanId != "" && n.LanName != "" {
+		errs = append(errs, errors.New("only one of 'lan_id' or 'lan_name' can be set"))
+	}
+	if (n.LanId != "" || n.LanName != "") && c.DatacenterId == "" && c.DatacenterName == "" {
+		errs = append(errs, errors.New("'lan_id' and 'lan_name' require 'datacenter_id' or 'datacenter_name'"))
+	}
+	if _, err := strconv.Atoi(n.LanId); n.LanId != "" && err != nil {
+		errs = append(errs, fmt.Errorf("'lan_id' must be a number: %q", n.LanId))
+	}
+	for _, ip := range append(append([]string(nil), n.Ips...), n.Ipv6Ips...) {
+		if net.ParseIP(ip) == nil {
+			errs = append(errs, fmt.Errorf("%q is not an IP address", ip))
+		}
+	}
+	return errs
+}
+
+// prepare sets the defaults of the volume and validates it.
+func (v *Volume) prepare() []error {
+	var errs []error
+	if v.Size <= 0 {
+		errs = append(errs, errors.New("'size' is required"))
+	}
+	if v.Type == "" {
+		v.Type = "HDD"
+	}
+	diskType, ok := canonicalDiskType(v.Type)
+	if !ok {
+		errs = append(errs, fmt.Errorf("unknown 'type' %q", v.Type))
+	}
+	v.Type = diskType
+	v.Bus = strings.ToUpper(v.Bus)
+	switch v.Bus {
+	case "", "VIRTIO", "IDE":
+	default:
+		errs = append(errs, errors.New("'bus' must be one of VIRTIO or IDE"))
+	}
+	if v.Image != "" && !isUUID(v.Image) {
+		errs = append(errs, fmt.Errorf("'image' must be the UUID of an image or snapshot: %q", v.Image))
+	}
+	if v.SnapshotName != "" && !v.Snapshot {
+		errs = append(errs, errors.New("'snapshot_name' requires 'snapshot'"))
+	}
+	return errs
+}
+
+// diskTypes are the volume types of the API in their canonical spelling.
+var diskTypes = []string{"HDD", "SSD", "SSD Standard", "SSD Premium"}
+
+// canonicalDiskType returns the API spelling of the volume type t, which is
+// matched case-insensitively, and false if t is not a known type.
+func canonicalDiskType(t string) (string, bool) {
+	for _, diskType := range diskTypes {
+		if strings.EqualFold(t, diskType) {
+			return diskType, true
+		}
+	}
+	return t, false
+}
+
+// prepareServerType sets the default server type and validates the server
+// settings that depend on it. It runs before the cores, RAM and disk
+// defaults are applied.
+func (c *Config) prepareServerType() []error {
+	var errs []error
+	if c.ServerType == "" {
+		c.ServerType = serverTypeEnterprise
+	}
+	c.ServerType = strings.ToUpper(c.ServerType)
+	switch c.ServerType {
+	case serverTypeEnterprise, serverTypeVcpu:
+		if c.TemplateUuid != "" || c.TemplateName != "" {
+			errs = append(errs, fmt.Errorf("'template_uuid' and 'template_name' require 'server_type' %s", serverTypeCube))
+		}
+	case serverTypeCube:
+		if (c.TemplateUuid == "") == (c.TemplateName == "") {
+			errs = append(errs, fmt.Errorf("'server_type' %s requires one of 'template_uuid' or 'template_name'", serverTypeCube))
+		}
+		if c.TemplateUuid != "" && !isUUID(c.TemplateUuid) {
+			errs = append(errs, fmt.Errorf("'template_uuid' is not a UUID: %q", c.TemplateUuid))
+		}
+		if c.Cores != 0 || c.Ram != 0 {
+			errs = append(errs, fmt.Errorf("'cores' and 'ram' can not be set for 'server_type' %s, they are given by the template", serverTypeCube))
+		}
+		if c.DiskSize != 0 {
+			errs = append(errs, fmt.Errorf("'disk_size' can not be set for 'server_type' %s, it is given by the template", serverTypeCube))
+		}
+		if c.DiskType != "" && !strings.EqualFold(c.DiskType, "DAS") {
+			errs = append(errs, fmt.Errorf("'disk_type' must be DAS for 'server_type' %s", serverTypeCube))
+		}
+		c.DiskType = strings.ToUpper(c.DiskType)
+	default:
+		errs = append(errs, fmt.Errorf("'server_type' must be one of %s, %s or %s",
+			serverTypeEnterprise, serverTypeCube, serverTypeVcpu))
+	}
+
+	if c.CpuFamily != "" && c.ServerType != serverTypeEnterprise {
+		errs = append(errs, fmt.Errorf("'cpu_family' can only be set for 'server_type' %s", serverTypeEnterprise))
+	}
+
+	c.AvailabilityZone = strings.ToUpper(c.AvailabilityZone)
+	switch c.AvailabilityZone {
+	case "", "AUTO", "ZONE_1", "ZONE_2":
+	default:
+		errs = append(errs, errors.New("'availability_zone' must be one of AUTO, ZONE_1 or ZONE_2"))
+	}
+	return errs
+}
+
+// prepareShutdown sets the defaults of the shutdown before the snapshot and
+// validates them.
+func (c *Config) prepareShutdown() []error {
+	var errs []error
+	if c.ShutdownBehavior == "" {
+		c.ShutdownBehavior = shutdownBehaviorNone
+	}
+	c.ShutdownBehavior = strings.ToLower(c.ShutdownBehavior)
+	switch c.ShutdownBehavior {
+	case shutdownBehaviorNone, shutdownBehaviorStop:
+		if c.ShutdownCommand != "" {
+			errs = append(errs, fmt.Errorf("'shutdown_command' requires 'shutdown_behavior' %s", shutdownBehaviorCommand))
+		}
+	case shutdownBehaviorCommand:
+		if c.ShutdownCommand == "" {
+			c.ShutdownCommand = "shutdown -P now"
+			if c.Comm.Type == "winrm" {
+				c.ShutdownCommand = "shutdown /s /t 0 /f"
+			}
+		}
+	default:
+		errs = append(errs, fmt.Errorf("'shutdown_behavior' must be one of %s, %s or %s",
+			shutdownBehaviorNone, shutdownBehaviorCommand, shutdownBehaviorStop))
+	}
+	if c.ShutdownBehavior == shutdownBehaviorStop && c.ServerType == serverTypeCube {
+		errs = append(errs, fmt.Errorf("'shutdown_behavior' %s is not supported by 'server_type' %s", shutdownBehaviorStop, serverTypeCube))
+	}
+
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = 5 * time.Minute
+	}
+	if c.ShutdownTimeout < 0 {
+		errs = append(errs, errors.New("'shutdown_timeout' must not be negative"))
+	}
+	return errs
+}
+
+// snapshotNames returns the names of all snapshots the build creates, the
+// boot volume snapshot first.
+func (c *Config) snapshotNames() []string {
+	names := []string{c.SnapshotName}
+	for _, v := range c.Volumes {
+		if v.SnapshotName != "" {
+			names = append(names, v.SnapshotName)
+		}
+	}
+	return names
+}
+
+// prepareLabels adds the build name and run UUID to the snapshot and run
+// labels, unless they are set explicitly.
+func (c *Config) prepareLabels() {
+	runUUID := os.Getenv("PACKER_RUN_UUID")
+	if runUUID == "" {
+		runUUID = uuid.TimeOrderedUUID()
+	}
+	defaults := map[string]string{
+		labelBuildName: c.PackerBuildName,
+		labelRunUUID:   runUUID,
+	}
+	if c.SnapshotLabels == nil {
+		c.SnapshotLabels = make(map[string]string)
+	}
+	if c.RunLabels == nil {
+		c.RunLabels = make(map[string]string)
+	}
+	for k, v := range defaults {
+		if v == "" {
+			continue
+		}
+		if _, ok := c.SnapshotLabels[k]; !ok {
+			c.SnapshotLabels[k] = v
+		}
+		if _, ok := c.RunLabels[k]; !ok {
+			c.RunLabels[k] = v
+		}
+	}
+}
+
+// usesBuildLan reports whether a NIC is connected to the build LAN, which
+// is created unless lan_id or lan_name is set.
+func (c *Config) usesBuildLan() bool {
+	for _, n := range c.Nics {
+		if n.LanId == "" && n.LanName == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// communicatorNic returns the NIC the communicator connects through, or nil
+// if communicator_nic names no NIC.
+func (c *Config) communicatorNic() *Nic {
+	for i := range c.Nics {
+		if c.Nics[i].Name == c.CommunicatorNic {
+			return &c.Nics[i]
+		}
+	}
+	return nil
+}
+
+func validImageVisibility(v string) bool {
+	switch v {
+	case imageVisibilityPublic, imageVisibilityPrivate, imageVisibilityAny:
+		return true
+	}
+	return false
 }
 
 func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
@@ -46,6 +481,7 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		InterpolateFilter: &interpolate.RenderFilter{
 			Exclude: []string{
 				"run_command",
+				"snapshot_description",
 			},
 		},
 	}, raws...)
@@ -75,27 +511,21 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		c.SnapshotName = def
 	}
 
-	if c.IonosUsername == "" {
-		c.IonosUsername = os.Getenv("IONOS_USERNAME")
-	}
-
-	if c.IonosPassword == "" {
-		c.IonosPassword = os.Getenv("IONOS_PASSWORD")
-	}
+	warnings, es := c.AccessConfig.Prepare()
+	errs = packersdk.MultiErrorAppend(errs, es...)
 
-	if c.IonosApiUrl == "" {
-		c.IonosApiUrl = "https://api.ionos.com"
-	}
+	errs = packersdk.MultiErrorAppend(errs, c.prepareServerType()...)
 
-	if c.Cores == 0 {
+	// a CUBE server gets its cores, RAM and disk size from the template
+	if c.Cores == 0 && c.ServerType != serverTypeCube {
 		c.Cores = 4
 	}
 
-	if c.Ram == 0 {
+	if c.Ram == 0 && c.ServerType != serverTypeCube {
 		c.Ram = 2048
 	}
 
-	if c.DiskSize == 0 {
+	if c.DiskSize == 0 && c.ServerType != serverTypeCube {
 		c.DiskSize = 50
 	}
 
@@ -103,33 +533,214 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		c.Region = "us/las"
 	}
 
+	if c.Ram%256 != 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'ram' must be a multiple of 256"))
+	}
+
+	c.prepareLabels()
+
+	c.SnapshotLicenceType = strings.ToUpper(c.SnapshotLicenceType)
+	switch c.SnapshotLicenceType {
+	case "", "LINUX", "RHEL", "WINDOWS", "WINDOWS2016", "WINDOWS2019", "WINDOWS2022", "UNKNOWN", "OTHER":
+	default:
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("unknown 'snapshot_licence_type' %q", c.SnapshotLicenceType))
+	}
+
+	if c.ForceDeleteSnapshot && c.SkipIfExists {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'force_delete_snapshot' and 'skip_if_exists' can not be used together"))
+	}
+	if c.SkipCreateSnapshot && (c.ForceDeleteSnapshot || c.SkipIfExists) {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'force_delete_snapshot' and 'skip_if_exists' can not be used with 'skip_create_snapshot'"))
+	}
+
+	errs = packersdk.MultiErrorAppend(errs, c.prepareShutdown()...)
+
+	if c.QuotaWaitTimeout < 0 {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'quota_wait_timeout' must not be negative"))
+	}
+	if c.QuotaWaitTimeout != 0 && !c.CheckQuota {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'quota_wait_timeout' requires 'check_quota'"))
+	}
+
 	if c.DiskType == "" {
 		c.DiskType = "HDD"
+		if c.ServerType == serverTypeCube {
+			c.DiskType = "DAS"
+		}
+	}
+	if c.ServerType != serverTypeCube {
+		diskType, ok := canonicalDiskType(c.DiskType)
+		if !ok {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("unknown 'disk_type' %q", c.DiskType))
+		}
+		c.DiskType = diskType
 	}
 
 	if es := c.Comm.Prepare(&c.ctx); len(es) > 0 {
 		errs = packersdk.MultiErrorAppend(errs, es...)
 	}
 
-	if c.Image == "" {
+	if c.ImageMatch == "" {
+		c.ImageMatch = imageMatchContains
+	}
+
+	if c.ImageVisibility == "" {
+		c.ImageVisibility = imageVisibilityPublic
+	}
+
+	if !validImageVisibility(c.ImageVisibility) {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("'image_visibility' must be one of %s, %s or %s",
+				imageVisibilityPublic, imageVisibilityPrivate, imageVisibilityAny))
+	}
+
+	sources := 0
+	for _, v := range []string{c.Image, c.ImageAlias, c.SourceSnapshot} {
+		if v != "" {
+			sources++
+		}
+	}
+	if !c.ImageFilter.empty() {
+		sources++
+		for _, err := range c.ImageFilter.Prepare() {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("image_filter: %w", err))
+		}
+		if c.ImageFilter.Location != "" && c.ImageFilter.Location != c.Region {
+			errs = packersdk.MultiErrorAppend(
+				errs, errors.New("image_filter 'location' must match the build 'location'"))
+		}
+	}
+	if sources == 0 {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("one of IONOS 'image', 'image_alias', 'image_filter' or 'source_snapshot' is required"))
+	} else if sources > 1 {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("only one of 'image', 'image_alias', 'image_filter' or 'source_snapshot' can be set"))
+	}
+
+	if c.DatacenterId != "" && c.DatacenterName != "" {
 		errs = packersdk.MultiErrorAppend(
-			errs, errors.New("IONOS 'image' is required"))
+			errs, errors.New("only one of 'datacenter_id' or 'datacenter_name' can be set"))
 	}
 
-	if c.IonosUsername == "" {
+	if c.LanId != "" && c.LanName != "" {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("only one of 'lan_id' or 'lan_name' can be set"))
+	}
+	if (c.LanId != "" || c.LanName != "") && c.DatacenterId == "" && c.DatacenterName == "" {
 		errs = packersdk.MultiErrorAppend(
-			errs, errors.New("IONOS username is required"))
+			errs, errors.New("'lan_id' and 'lan_name' require 'datacenter_id' or 'datacenter_name'"))
+	}
+	if _, err := strconv.Atoi(c.LanId); c.LanId != "" && err != nil {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("'lan_id' must be a number: %q", c.LanId))
 	}
 
-	if c.IonosPassword == "" {
+	if c.ReserveIp && c.IpBlockId != "" {
 		errs = packersdk.MultiErrorAppend(
-			errs, errors.New("IONOS password is required"))
+			errs, errors.New("only one of 'reserve_ip' or 'ip_block_id' can be set"))
+	}
+
+	if len(c.Nics) == 0 {
+		c.Nics = []Nic{{}}
+	}
+	firewallActive := false
+	names := make(map[string]bool)
+	for i := range c.Nics {
+		n := &c.Nics[i]
+		if n.Name == "" {
+			n.Name = c.SnapshotName
+			if i > 0 {
+				n.Name = fmt.Sprintf("%s-%d", c.SnapshotName, i)
+			}
+		}
+		if names[n.Name] {
+			errs = packersdk.MultiErrorAppend(
+				errs, fmt.Errorf("nic %d: name %q is used by another NIC", i, n.Name))
+		}
+		names[n.Name] = true
+		if n.Dhcp == nil {
+			dhcp := true
+			n.Dhcp = &dhcp
+		}
+		if n.FirewallActive == nil {
+			active := c.FirewallActive
+			n.FirewallActive = &active
+		}
+		firewallActive = firewallActive || *n.FirewallActive
+		for _, err := range n.prepare(c) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("nic %d: %w", i, err))
+		}
+	}
+	if c.CommunicatorNic == "" {
+		c.CommunicatorNic = c.Nics[0].Name
+	}
+	commNic := c.communicatorNic()
+	if commNic == nil {
+		errs = packersdk.MultiErrorAppend(
+			errs, fmt.Errorf("'communicator_nic': no NIC is named %q", c.CommunicatorNic))
+	}
+	if commNic != nil && (c.ReserveIp || c.IpBlockId != "") &&
+		(commNic.LanId != "" || commNic.LanName != "" || c.LanId != "" || c.LanName != "") {
+		// a reserved IP is only reachable on the public LAN the build creates
+		errs = packersdk.MultiErrorAppend(errs, errors.New(
+			"'reserve_ip' and 'ip_block_id' can not be used when the 'communicator_nic' is on an existing LAN"))
+	}
+
+	volumeNames := map[string]bool{c.SnapshotName: true}
+	for i := range c.Volumes {
+		v := &c.Volumes[i]
+		if v.Name == "" {
+			v.Name = fmt.Sprintf("data-%d", i)
+		}
+		if volumeNames[v.Name] {
+			errs = packersdk.MultiErrorAppend(
+				errs, fmt.Errorf("volume %d: name %q is used by another volume", i, v.Name))
+		}
+		volumeNames[v.Name] = true
+		if v.Snapshot && v.SnapshotName == "" {
+			v.SnapshotName = fmt.Sprintf("%s-%s", c.SnapshotName, v.Name)
+		}
+		for _, err := range v.prepare() {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("volume %d: %w", i, err))
+		}
+	}
+
+	if !firewallActive && (len(c.FirewallRules) > 0 || len(c.FirewallSourceCidrs) > 0 || c.FirewallSourcePublicIp) {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("'firewall_rule', 'firewall_source_cidrs' and 'firewall_source_public_ip' require 'firewall_active'"))
+	}
+	for i := range c.FirewallRules {
+		for _, err := range c.FirewallRules[i].Prepare() {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("firewall_rule %d: %w", i, err))
+		}
+	}
+	for _, cidr := range c.FirewallSourceCidrs {
+		if !validIpOrCidr(cidr) {
+			errs = packersdk.MultiErrorAppend(
+				errs, fmt.Errorf("'firewall_source_cidrs': %q is not an IP address or CIDR block", cidr))
+		}
+	}
+	if commNic != nil && *commNic.FirewallActive && c.Comm.Type != "none" &&
+		len(c.FirewallSourceCidrs) == 0 && !c.FirewallSourcePublicIp {
+		// the communicator port would not be opened for anyone
+		errs = packersdk.MultiErrorAppend(errs, errors.New(
+			"'firewall_active' requires 'firewall_source_cidrs' or 'firewall_source_public_ip' to reach the communicator"))
+	}
+
+	for _, name := range []string{c.Image, c.SourceSnapshot} {
+		if name == "" || isUUID(name) {
+			continue
+		}
+		if _, err := newNameMatcher(name, c.ImageMatch); err != nil {
+			errs = packersdk.MultiErrorAppend(
+				errs, fmt.Errorf("invalid image name or 'image_match': %w", err))
+		}
 	}
 
 	if errs != nil && len(errs.Errors) > 0 {
-		return nil, errs
+		return warnings, errs
 	}
-	packersdk.LogSecretFilter.Set(c.IonosUsername)
 
-	return nil, nil
+	return warnings, nil
 }
@@ -10,74 +10,126 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName           *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType         *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion         *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug               *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce               *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError             *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars            map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars       []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	Type                      *string           `mapstructure:"communicator" cty:"communicator" hcl:"communicator"`
-	PauseBeforeConnect        *string           `mapstructure:"pause_before_connecting" cty:"pause_before_connecting" hcl:"pause_before_connecting"`
-	SSHHost                   *string           `mapstructure:"ssh_host" cty:"ssh_host" hcl:"ssh_host"`
-	SSHPort                   *int              `mapstructure:"ssh_port" cty:"ssh_port" hcl:"ssh_port"`
-	SSHUsername               *string           `mapstructure:"ssh_username" cty:"ssh_username" hcl:"ssh_username"`
-	SSHPassword               *string           `mapstructure:"ssh_password" cty:"ssh_password" hcl:"ssh_password"`
-	SSHKeyPairName            *string           `mapstructure:"ssh_keypair_name" undocumented:"true" cty:"ssh_keypair_name" hcl:"ssh_keypair_name"`
-	SSHTemporaryKeyPairName   *string           `mapstructure:"temporary_key_pair_name" undocumented:"true" cty:"temporary_key_pair_name" hcl:"temporary_key_pair_name"`
-	SSHTemporaryKeyPairType   *string           `mapstructure:"temporary_key_pair_type" cty:"temporary_key_pair_type" hcl:"temporary_key_pair_type"`
-	SSHTemporaryKeyPairBits   *int              `mapstructure:"temporary_key_pair_bits" cty:"temporary_key_pair_bits" hcl:"temporary_key_pair_bits"`
-	SSHCiphers                []string          `mapstructure:"ssh_ciphers" cty:"ssh_ciphers" hcl:"ssh_ciphers"`
-	SSHClearAuthorizedKeys    *bool             `mapstructure:"ssh_clear_authorized_keys" cty:"ssh_clear_authorized_keys" hcl:"ssh_clear_authorized_keys"`
-	SSHKEXAlgos               []string          `mapstructure:"ssh_key_exchange_algorithms" cty:"ssh_key_exchange_algorithms" hcl:"ssh_key_exchange_algorithms"`
-	SSHPrivateKeyFile         *string           `mapstructure:"ssh_private_key_file" undocumented:"true" cty:"ssh_private_key_file" hcl:"ssh_private_key_file"`
-	SSHCertificateFile        *string           `mapstructure:"ssh_certificate_file" cty:"ssh_certificate_file" hcl:"ssh_certificate_file"`
-	SSHPty                    *bool             `mapstructure:"ssh_pty" cty:"ssh_pty" hcl:"ssh_pty"`
-	SSHTimeout                *string           `mapstructure:"ssh_timeout" cty:"ssh_timeout" hcl:"ssh_timeout"`
-	SSHWaitTimeout            *string           `mapstructure:"ssh_wait_timeout" undocumented:"true" cty:"ssh_wait_timeout" hcl:"ssh_wait_timeout"`
-	SSHAgentAuth              *bool             `mapstructure:"ssh_agent_auth" undocumented:"true" cty:"ssh_agent_auth" hcl:"ssh_agent_auth"`
-	SSHDisableAgentForwarding *bool             `mapstructure:"ssh_disable_agent_forwarding" cty:"ssh_disable_agent_forwarding" hcl:"ssh_disable_agent_forwarding"`
-	SSHHandshakeAttempts      *int              `mapstructure:"ssh_handshake_attempts" cty:"ssh_handshake_attempts" hcl:"ssh_handshake_attempts"`
-	SSHBastionHost            *string           `mapstructure:"ssh_bastion_host" cty:"ssh_bastion_host" hcl:"ssh_bastion_host"`
-	SSHBastionPort            *int              `mapstructure:"ssh_bastion_port" cty:"ssh_bastion_port" hcl:"ssh_bastion_port"`
-	SSHBastionAgentAuth       *bool             `mapstructure:"ssh_bastion_agent_auth" cty:"ssh_bastion_agent_auth" hcl:"ssh_bastion_agent_auth"`
-	SSHBastionUsername        *string           `mapstructure:"ssh_bastion_username" cty:"ssh_bastion_username" hcl:"ssh_bastion_username"`
-	SSHBastionPassword        *string           `mapstructure:"ssh_bastion_password" cty:"ssh_bastion_password" hcl:"ssh_bastion_password"`
-	SSHBastionInteractive     *bool             `mapstructure:"ssh_bastion_interactive" cty:"ssh_bastion_interactive" hcl:"ssh_bastion_interactive"`
-	SSHBastionPrivateKeyFile  *string           `mapstructure:"ssh_bastion_private_key_file" cty:"ssh_bastion_private_key_file" hcl:"ssh_bastion_private_key_file"`
-	SSHBastionCertificateFile *string           `mapstructure:"ssh_bastion_certificate_file" cty:"ssh_bastion_certificate_file" hcl:"ssh_bastion_certificate_file"`
-	SSHFileTransferMethod     *string           `mapstructure:"ssh_file_transfer_method" cty:"ssh_file_transfer_method" hcl:"ssh_file_transfer_method"`
-	SSHProxyHost              *string           `mapstructure:"ssh_proxy_host" cty:"ssh_proxy_host" hcl:"ssh_proxy_host"`
-	SSHProxyPort              *int              `mapstructure:"ssh_proxy_port" cty:"ssh_proxy_port" hcl:"ssh_proxy_port"`
-	SSHProxyUsername          *string           `mapstructure:"ssh_proxy_username" cty:"ssh_proxy_username" hcl:"ssh_proxy_username"`
-	SSHProxyPassword          *string           `mapstructure:"ssh_proxy_password" cty:"ssh_proxy_password" hcl:"ssh_proxy_password"`
-	SSHKeepAliveInterval      *string           `mapstructure:"ssh_keep_alive_interval" cty:"ssh_keep_alive_interval" hcl:"ssh_keep_alive_interval"`
-	SSHReadWriteTimeout       *string           `mapstructure:"ssh_read_write_timeout" cty:"ssh_read_write_timeout" hcl:"ssh_read_write_timeout"`
-	SSHRemoteTunnels          []string          `mapstructure:"ssh_remote_tunnels" cty:"ssh_remote_tunnels" hcl:"ssh_remote_tunnels"`
-	SSHLocalTunnels           []string          `mapstructure:"ssh_local_tunnels" cty:"ssh_local_tunnels" hcl:"ssh_local_tunnels"`
-	SSHPublicKey              []byte            `mapstructure:"ssh_public_key" undocumented:"true" cty:"ssh_public_key" hcl:"ssh_public_key"`
-	SSHPrivateKey             []byte            `mapstructure:"ssh_private_key" undocumented:"true" cty:"ssh_private_key" hcl:"ssh_private_key"`
-	WinRMUser                 *string           `mapstructure:"winrm_username" cty:"winrm_username" hcl:"winrm_username"`
-	WinRMPassword             *string           `mapstructure:"winrm_password" cty:"winrm_password" hcl:"winrm_password"`
-	WinRMHost                 *string           `mapstructure:"winrm_host" cty:"winrm_host" hcl:"winrm_host"`
-	WinRMNoProxy              *bool             `mapstructure:"winrm_no_proxy" cty:"winrm_no_proxy" hcl:"winrm_no_proxy"`
-	WinRMPort                 *int              `mapstructure:"winrm_port" cty:"winrm_port" hcl:"winrm_port"`
-	WinRMTimeout              *string           `mapstructure:"winrm_timeout" cty:"winrm_timeout" hcl:"winrm_timeout"`
-	WinRMUseSSL               *bool             `mapstructure:"winrm_use_ssl" cty:"winrm_use_ssl" hcl:"winrm_use_ssl"`
-	WinRMInsecure             *bool             `mapstructure:"winrm_insecure" cty:"winrm_insecure" hcl:"winrm_insecure"`
-	WinRMUseNTLM              *bool             `mapstructure:"winrm_use_ntlm" cty:"winrm_use_ntlm" hcl:"winrm_use_ntlm"`
-	IonosUsername             *string           `mapstructure:"username" cty:"username" hcl:"username"`
-	IonosPassword             *string           `mapstructure:"password" cty:"password" hcl:"password"`
-	IonosApiUrl               *string           `mapstructure:"url" cty:"url" hcl:"url"`
-	Region                    *string           `mapstructure:"location" cty:"location" hcl:"location"`
-	Image                     *string           `mapstructure:"image" cty:"image" hcl:"image"`
-	SnapshotName              *string           `mapstructure:"snapshot_name" cty:"snapshot_name" hcl:"snapshot_name"`
-	DiskSize                  *float32          `mapstructure:"disk_size" cty:"disk_size" hcl:"disk_size"`
-	DiskType                  *string           `mapstructure:"disk_type" cty:"disk_type" hcl:"disk_type"`
-	Cores                     *int32            `mapstructure:"cores" cty:"cores" hcl:"cores"`
-	Ram                       *int32            `mapstructure:"ram" cty:"ram" hcl:"ram"`
-	Retries                   *int              `mapstructure:"retries" cty:"retries" hcl:"retries"`
+	PackerBuildName             *string            `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType           *string            `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion           *string            `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug                 *bool              `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce                 *bool              `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError               *string            `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars              map[string]string  `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars         []string           `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	Type                        *string            `mapstructure:"communicator" cty:"communicator" hcl:"communicator"`
+	PauseBeforeConnect          *string            `mapstructure:"pause_before_connecting" cty:"pause_before_connecting" hcl:"pause_before_connecting"`
+	SSHHost                     *string            `mapstructure:"ssh_host" cty:"ssh_host" hcl:"ssh_host"`
+	SSHPort                     *int               `mapstructure:"ssh_port" cty:"ssh_port" hcl:"ssh_port"`
+	SSHUsername                 *string            `mapstructure:"ssh_username" cty:"ssh_username" hcl:"ssh_username"`
+	SSHPassword                 *string            `mapstructure:"ssh_password" cty:"ssh_password" hcl:"ssh_password"`
+	SSHKeyPairName              *string            `mapstructure:"ssh_keypair_name" undocumented:"true" cty:"ssh_keypair_name" hcl:"ssh_keypair_name"`
+	SSHTemporaryKeyPairName     *string            `mapstructure:"temporary_key_pair_name" undocumented:"true" cty:"temporary_key_pair_name" hcl:"temporary_key_pair_name"`
+	SSHTemporaryKeyPairType     *string            `mapstructure:"temporary_key_pair_type" cty:"temporary_key_pair_type" hcl:"temporary_key_pair_type"`
+	SSHTemporaryKeyPairBits     *int               `mapstructure:"temporary_key_pair_bits" cty:"temporary_key_pair_bits" hcl:"temporary_key_pair_bits"`
+	SSHCiphers                  []string           `mapstructure:"ssh_ciphers" cty:"ssh_ciphers" hcl:"ssh_ciphers"`
+	SSHClearAuthorizedKeys      *bool              `mapstructure:"ssh_clear_authorized_keys" cty:"ssh_clear_authorized_keys" hcl:"ssh_clear_authorized_keys"`
+	SSHKEXAlgos                 []string           `mapstructure:"ssh_key_exchange_algorithms" cty:"ssh_key_exchange_algorithms" hcl:"ssh_key_exchange_algorithms"`
+	SSHPrivateKeyFile           *string            `mapstructure:"ssh_private_key_file" undocumented:"true" cty:"ssh_private_key_file" hcl:"ssh_private_key_file"`
+	SSHCertificateFile          *string            `mapstructure:"ssh_certificate_file" cty:"ssh_certificate_file" hcl:"ssh_certificate_file"`
+	SSHPty                      *bool              `mapstructure:"ssh_pty" cty:"ssh_pty" hcl:"ssh_pty"`
+	SSHTimeout                  *string            `mapstructure:"ssh_timeout" cty:"ssh_timeout" hcl:"ssh_timeout"`
+	SSHWaitTimeout              *string            `mapstructure:"ssh_wait_timeout" undocumented:"true" cty:"ssh_wait_timeout" hcl:"ssh_wait_timeout"`
+	SSHAgentAuth                *bool              `mapstructure:"ssh_agent_auth" undocumented:"true" cty:"ssh_agent_auth" hcl:"ssh_agent_auth"`
+	SSHDisableAgentForwarding   *bool              `mapstructure:"ssh_disable_agent_forwarding" cty:"ssh_disable_agent_forwarding" hcl:"ssh_disable_agent_forwarding"`
+	SSHHandshakeAttempts        *int               `mapstructure:"ssh_handshake_attempts" cty:"ssh_handshake_attempts" hcl:"ssh_handshake_attempts"`
+	SSHBastionHost              *string            `mapstructure:"ssh_bastion_host" cty:"ssh_bastion_host" hcl:"ssh_bastion_host"`
+	SSHBastionPort              *int               `mapstructure:"ssh_bastion_port" cty:"ssh_bastion_port" hcl:"ssh_bastion_port"`
+	SSHBastionAgentAuth         *bool              `mapstructure:"ssh_bastion_agent_auth" cty:"ssh_bastion_agent_auth" hcl:"ssh_bastion_agent_auth"`
+	SSHBastionUsername          *string            `mapstructure:"ssh_bastion_username" cty:"ssh_bastion_username" hcl:"ssh_bastion_username"`
+	SSHBastionPassword          *string            `mapstructure:"ssh_bastion_password" cty:"ssh_bastion_password" hcl:"ssh_bastion_password"`
+	SSHBastionInteractive       *bool              `mapstructure:"ssh_bastion_interactive" cty:"ssh_bastion_interactive" hcl:"ssh_bastion_interactive"`
+	SSHBastionPrivateKeyFile    *string            `mapstructure:"ssh_bastion_private_key_file" cty:"ssh_bastion_private_key_file" hcl:"ssh_bastion_private_key_file"`
+	SSHBastionCertificateFile   *string            `mapstructure:"ssh_bastion_certificate_file" cty:"ssh_bastion_certificate_file" hcl:"ssh_bastion_certificate_file"`
+	SSHFileTransferMethod       *string            `mapstructure:"ssh_file_transfer_method" cty:"ssh_file_transfer_method" hcl:"ssh_file_transfer_method"`
+	SSHProxyHost                *string            `mapstructure:"ssh_proxy_host" cty:"ssh_proxy_host" hcl:"ssh_proxy_host"`
+	SSHProxyPort                *int               `mapstructure:"ssh_proxy_port" cty:"ssh_proxy_port" hcl:"ssh_proxy_port"`
+	SSHProxyUsername            *string            `mapstructure:"ssh_proxy_username" cty:"ssh_proxy_username" hcl:"ssh_proxy_username"`
+	SSHProxyPassword            *string            `mapstructure:"ssh_proxy_password" cty:"ssh_proxy_password" hcl:"ssh_proxy_password"`
+	SSHKeepAliveInterval        *string            `mapstructure:"ssh_keep_alive_interval" cty:"ssh_keep_alive_interval" hcl:"ssh_keep_alive_interval"`
+	SSHReadWriteTimeout         *string            `mapstructure:"ssh_read_write_timeout" cty:"ssh_read_write_timeout" hcl:"ssh_read_write_timeout"`
+	SSHRemoteTunnels            []string           `mapstructure:"ssh_remote_tunnels" cty:"ssh_remote_tunnels" hcl:"ssh_remote_tunnels"`
+	SSHLocalTunnels             []string           `mapstructure:"ssh_local_tunnels" cty:"ssh_local_tunnels" hcl:"ssh_local_tunnels"`
+	SSHPublicKey                []byte             `mapstructure:"ssh_public_key" undocumented:"true" cty:"ssh_public_key" hcl:"ssh_public_key"`
+	SSHPrivateKey               []byte             `mapstructure:"ssh_private_key" undocumented:"true" cty:"ssh_private_key" hcl:"ssh_private_key"`
+	WinRMUser                   *string            `mapstructure:"winrm_username" cty:"winrm_username" hcl:"winrm_username"`
+	WinRMPassword               *string            `mapstructure:"winrm_password" cty:"winrm_password" hcl:"winrm_password"`
+	WinRMHost                   *string            `mapstructure:"winrm_host" cty:"winrm_host" hcl:"winrm_host"`
+	WinRMNoProxy                *bool              `mapstructure:"winrm_no_proxy" cty:"winrm_no_proxy" hcl:"winrm_no_proxy"`
+	WinRMPort                   *int               `mapstructure:"winrm_port" cty:"winrm_port" hcl:"winrm_port"`
+	WinRMTimeout                *string            `mapstructure:"winrm_timeout" cty:"winrm_timeout" hcl:"winrm_timeout"`
+	WinRMUseSSL                 *bool              `mapstructure:"winrm_use_ssl" cty:"winrm_use_ssl" hcl:"winrm_use_ssl"`
+	WinRMInsecure               *bool              `mapstructure:"winrm_insecure" cty:"winrm_insecure" hcl:"winrm_insecure"`
+	WinRMUseNTLM                *bool              `mapstructure:"winrm_use_ntlm" cty:"winrm_use_ntlm" hcl:"winrm_use_ntlm"`
+	IonosUsername               *string            `mapstructure:"username" cty:"username" hcl:"username"`
+	IonosPassword               *string            `mapstructure:"password" cty:"password" hcl:"password"`
+	IonosToken                  *string            `mapstructure:"token" cty:"token" hcl:"token"`
+	IonosApiUrl                 *string            `mapstructure:"url" cty:"url" hcl:"url"`
+	Profile                     *string            `mapstructure:"profile" cty:"profile" hcl:"profile"`
+	CredentialsFile             *string            `mapstructure:"credentials_file" cty:"credentials_file" hcl:"credentials_file"`
+	Retries                     *int               `mapstructure:"retries" cty:"retries" hcl:"retries"`
+	RetryWaitMin                *string            `mapstructure:"retry_wait_min" cty:"retry_wait_min" hcl:"retry_wait_min"`
+	RetryWaitMax                *string            `mapstructure:"retry_wait_max" cty:"retry_wait_max" hcl:"retry_wait_max"`
+	Region                      *string            `mapstructure:"location" cty:"location" hcl:"location"`
+	Image                       *string            `mapstructure:"image" cty:"image" hcl:"image"`
+	SnapshotName                *string            `mapstructure:"snapshot_name" cty:"snapshot_name" hcl:"snapshot_name"`
+	DiskSize                    *float32           `mapstructure:"disk_size" cty:"disk_size" hcl:"disk_size"`
+	DiskType                    *string            `mapstructure:"disk_type" cty:"disk_type" hcl:"disk_type"`
+	Cores                       *int32             `mapstructure:"cores" cty:"cores" hcl:"cores"`
+	Ram                         *int32             `mapstructure:"ram" cty:"ram" hcl:"ram"`
+	ServerType                  *string            `mapstructure:"server_type" cty:"server_type" hcl:"server_type"`
+	TemplateUuid                *string            `mapstructure:"template_uuid" cty:"template_uuid" hcl:"template_uuid"`
+	TemplateName                *string            `mapstructure:"template_name" cty:"template_name" hcl:"template_name"`
+	CpuFamily                   *string            `mapstructure:"cpu_family" cty:"cpu_family" hcl:"cpu_family"`
+	AvailabilityZone            *string            `mapstructure:"availability_zone" cty:"availability_zone" hcl:"availability_zone"`
+	ImageAlias                  *string            `mapstructure:"image_alias" cty:"image_alias" hcl:"image_alias"`
+	ImageMatch                  *string            `mapstructure:"image_match" cty:"image_match" hcl:"image_match"`
+	ImageMostRecent             *bool              `mapstructure:"image_most_recent" cty:"image_most_recent" hcl:"image_most_recent"`
+	ImageVisibility             *string            `mapstructure:"image_visibility" cty:"image_visibility" hcl:"image_visibility"`
+	SourceSnapshot              *string            `mapstructure:"source_snapshot" cty:"source_snapshot" hcl:"source_snapshot"`
+	ImageFilter                 *FlatImageFilter   `mapstructure:"image_filter" cty:"image_filter" hcl:"image_filter"`
+	DatacenterId                *string            `mapstructure:"datacenter_id" cty:"datacenter_id" hcl:"datacenter_id"`
+	DatacenterName              *string            `mapstructure:"datacenter_name" cty:"datacenter_name" hcl:"datacenter_name"`
+	LanId                       *string            `mapstructure:"lan_id" cty:"lan_id" hcl:"lan_id"`
+	LanName                     *string            `mapstructure:"lan_name" cty:"lan_name" hcl:"lan_name"`
+	ReserveIp                   *bool              `mapstructure:"reserve_ip" cty:"reserve_ip" hcl:"reserve_ip"`
+	IpBlockId                   *string            `mapstructure:"ip_block_id" cty:"ip_block_id" hcl:"ip_block_id"`
+	FirewallActive              *bool              `mapstructure:"firewall_active" cty:"firewall_active" hcl:"firewall_active"`
+	FirewallRules               []FlatFirewallRule `mapstructure:"firewall_rule" cty:"firewall_rule" hcl:"firewall_rule"`
+	FirewallSourceCidrs         []string           `mapstructure:"firewall_source_cidrs" cty:"firewall_source_cidrs" hcl:"firewall_source_cidrs"`
+	FirewallSourcePublicIp      *bool              `mapstructure:"firewall_source_public_ip" cty:"firewall_source_public_ip" hcl:"firewall_source_public_ip"`
+	Nics                        []FlatNic          `mapstructure:"nic" cty:"nic" hcl:"nic"`
+	CommunicatorNic             *string            `mapstructure:"communicator_nic" cty:"communicator_nic" hcl:"communicator_nic"`
+	Volumes                     []FlatVolume       `mapstructure:"volume" cty:"volume" hcl:"volume"`
+	SnapshotDescription         *string            `mapstructure:"snapshot_description" cty:"snapshot_description" hcl:"snapshot_description"`
+	SnapshotLicenceType         *string            `mapstructure:"snapshot_licence_type" cty:"snapshot_licence_type" hcl:"snapshot_licence_type"`
+	SnapshotSecAuthProtection   *bool              `mapstructure:"snapshot_sec_auth_protection" cty:"snapshot_sec_auth_protection" hcl:"snapshot_sec_auth_protection"`
+	SnapshotCpuHotPlug          *bool              `mapstructure:"snapshot_cpu_hot_plug" cty:"snapshot_cpu_hot_plug" hcl:"snapshot_cpu_hot_plug"`
+	SnapshotCpuHotUnplug        *bool              `mapstructure:"snapshot_cpu_hot_unplug" cty:"snapshot_cpu_hot_unplug" hcl:"snapshot_cpu_hot_unplug"`
+	SnapshotRamHotPlug          *bool              `mapstructure:"snapshot_ram_hot_plug" cty:"snapshot_ram_hot_plug" hcl:"snapshot_ram_hot_plug"`
+	SnapshotRamHotUnplug        *bool              `mapstructure:"snapshot_ram_hot_unplug" cty:"snapshot_ram_hot_unplug" hcl:"snapshot_ram_hot_unplug"`
+	SnapshotNicHotPlug          *bool              `mapstructure:"snapshot_nic_hot_plug" cty:"snapshot_nic_hot_plug" hcl:"snapshot_nic_hot_plug"`
+	SnapshotNicHotUnplug        *bool              `mapstructure:"snapshot_nic_hot_unplug" cty:"snapshot_nic_hot_unplug" hcl:"snapshot_nic_hot_unplug"`
+	SnapshotDiscVirtioHotPlug   *bool              `mapstructure:"snapshot_disc_virtio_hot_plug" cty:"snapshot_disc_virtio_hot_plug" hcl:"snapshot_disc_virtio_hot_plug"`
+	SnapshotDiscVirtioHotUnplug *bool              `mapstructure:"snapshot_disc_virtio_hot_unplug" cty:"snapshot_disc_virtio_hot_unplug" hcl:"snapshot_disc_virtio_hot_unplug"`
+	SnapshotDiscScsiHotPlug     *bool              `mapstructure:"snapshot_disc_scsi_hot_plug" cty:"snapshot_disc_scsi_hot_plug" hcl:"snapshot_disc_scsi_hot_plug"`
+	SnapshotDiscScsiHotUnplug   *bool              `mapstructure:"snapshot_disc_scsi_hot_unplug" cty:"snapshot_disc_scsi_hot_unplug" hcl:"snapshot_disc_scsi_hot_unplug"`
+	ForceDeleteSnapshot         *bool              `mapstructure:"force_delete_snapshot" cty:"force_delete_snapshot" hcl:"force_delete_snapshot"`
+	SkipIfExists                *bool              `mapstructure:"skip_if_exists" cty:"skip_if_exists" hcl:"skip_if_exists"`
+	SkipCreateSnapshot          *bool              `mapstructure:"skip_create_snapshot" cty:"skip_create_snapshot" hcl:"skip_create_snapshot"`
+	ShutdownBehavior            *string            `mapstructure:"shutdown_behavior" cty:"shutdown_behavior" hcl:"shutdown_behavior"`
+	ShutdownCommand             *string            `mapstructure:"shutdown_command" cty:"shutdown_command" hcl:"shutdown_command"`
+	ShutdownTimeout             *string            `mapstructure:"shutdown_timeout" cty:"shutdown_timeout" hcl:"shutdown_timeout"`
+	SnapshotLabels              map[string]string  `mapstructure:"snapshot_labels" cty:"snapshot_labels" hcl:"snapshot_labels"`
+	RunLabels                   map[string]string  `mapstructure:"run_labels" cty:"run_labels" hcl:"run_labels"`
+	CheckQuota                  *bool              `mapstructure:"check_quota" cty:"check_quota" hcl:"check_quota"`
+	QuotaWaitTimeout            *string            `mapstructure:"quota_wait_timeout" cty:"quota_wait_timeout" hcl:"quota_wait_timeout"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -92,74 +144,270 @@ func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec }
 // The decoded values from this spec will then be applied to a FlatConfig.
 func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"packer_build_name":            &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
-		"packer_builder_type":          &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
-		"packer_core_version":          &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
-		"packer_debug":                 &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
-		"packer_force":                 &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
-		"packer_on_error":              &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
-		"packer_user_variables":        &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
-		"packer_sensitive_variables":   &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
-		"communicator":                 &hcldec.AttrSpec{Name: "communicator", Type: cty.String, Required: false},
-		"pause_before_connecting":      &hcldec.AttrSpec{Name: "pause_before_connecting", Type: cty.String, Required: false},
-		"ssh_host":                     &hcldec.AttrSpec{Name: "ssh_host", Type: cty.String, Required: false},
-		"ssh_port":                     &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
-		"ssh_username":                 &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
-		"ssh_password":                 &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
-		"ssh_keypair_name":             &hcldec.AttrSpec{Name: "ssh_keypair_name", Type: cty.String, Required: false},
-		"temporary_key_pair_name":      &hcldec.AttrSpec{Name: "temporary_key_pair_name", Type: cty.String, Required: false},
-		"temporary_key_pair_type":      &hcldec.AttrSpec{Name: "temporary_key_pair_type", Type: cty.String, Required: false},
-		"temporary_key_pair_bits":      &hcldec.AttrSpec{Name: "temporary_key_pair_bits", Type: cty.Number, Required: false},
-		"ssh_ciphers":                  &hcldec.AttrSpec{Name: "ssh_ciphers", Type: cty.List(cty.String), Required: false},
-		"ssh_clear_authorized_keys":    &hcldec.AttrSpec{Name: "ssh_clear_authorized_keys", Type: cty.Bool, Required: false},
-		"ssh_key_exchange_algorithms":  &hcldec.AttrSpec{Name: "ssh_key_exchange_algorithms", Type: cty.List(cty.String), Required: false},
-		"ssh_private_key_file":         &hcldec.AttrSpec{Name: "ssh_private_key_file", Type: cty.String, Required: false},
-		"ssh_certificate_file":         &hcldec.AttrSpec{Name: "ssh_certificate_file", Type: cty.String, Required: false},
-		"ssh_pty":                      &hcldec.AttrSpec{Name: "ssh_pty", Type: cty.Bool, Required: false},
-		"ssh_timeout":                  &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
-		"ssh_wait_timeout":             &hcldec.AttrSpec{Name: "ssh_wait_timeout", Type: cty.String, Required: false},
-		"ssh_agent_auth":               &hcldec.AttrSpec{Name: "ssh_agent_auth", Type: cty.Bool, Required: false},
-		"ssh_disable_agent_forwarding": &hcldec.AttrSpec{Name: "ssh_disable_agent_forwarding", Type: cty.Bool, Required: false},
-		"ssh_handshake_attempts":       &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
-		"ssh_bastion_host":             &hcldec.AttrSpec{Name: "ssh_bastion_host", Type: cty.String, Required: false},
-		"ssh_bastion_port":             &hcldec.AttrSpec{Name: "ssh_bastion_port", Type: cty.Number, Required: false},
-		"ssh_bastion_agent_auth":       &hcldec.AttrSpec{Name: "ssh_bastion_agent_auth", Type: cty.Bool, Required: false},
-		"ssh_bastion_username":         &hcldec.AttrSpec{Name: "ssh_bastion_username", Type: cty.String, Required: false},
-		"ssh_bastion_password":         &hcldec.AttrSpec{Name: "ssh_bastion_password", Type: cty.String, Required: false},
-		"ssh_bastion_interactive":      &hcldec.AttrSpec{Name: "ssh_bastion_interactive", Type: cty.Bool, Required: false},
-		"ssh_bastion_private_key_file": &hcldec.AttrSpec{Name: "ssh_bastion_private_key_file", Type: cty.String, Required: false},
-		"ssh_bastion_certificate_file": &hcldec.AttrSpec{Name: "ssh_bastion_certificate_file", Type: cty.String, Required: false},
-		"ssh_file_transfer_method":     &hcldec.AttrSpec{Name: "ssh_file_transfer_method", Type: cty.String, Required: false},
-		"ssh_proxy_host":               &hcldec.AttrSpec{Name: "ssh_proxy_host", Type: cty.String, Required: false},
-		"ssh_proxy_port":               &hcldec.AttrSpec{Name: "ssh_proxy_port", Type: cty.Number, Required: false},
-		"ssh_proxy_username":           &hcldec.AttrSpec{Name: "ssh_proxy_username", Type: cty.String, Required: false},
-		"ssh_proxy_password":           &hcldec.AttrSpec{Name: "ssh_proxy_password", Type: cty.String, Required: false},
-		"ssh_keep_alive_interval":      &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
-		"ssh_read_write_timeout":       &hcldec.AttrSpec{Name: "ssh_read_write_timeout", Type: cty.String, Required: false},
-		"ssh_remote_tunnels":           &hcldec.AttrSpec{Name: "ssh_remote_tunnels", Type: cty.List(cty.String), Required: false},
-		"ssh_local_tunnels":            &hcldec.AttrSpec{Name: "ssh_local_tunnels", Type: cty.List(cty.String), Required: false},
-		"ssh_public_key":               &hcldec.AttrSpec{Name: "ssh_public_key", Type: cty.List(cty.Number), Required: false},
-		"ssh_private_key":              &hcldec.AttrSpec{Name: "ssh_private_key", Type: cty.List(cty.Number), Required: false},
-		"winrm_username":               &hcldec.AttrSpec{Name: "winrm_username", Type: cty.String, Required: false},
-		"winrm_password":               &hcldec.AttrSpec{Name: "winrm_password", Type: cty.String, Required: false},
-		"winrm_host":                   &hcldec.AttrSpec{Name: "winrm_host", Type: cty.String, Required: false},
-		"winrm_no_proxy":               &hcldec.AttrSpec{Name: "winrm_no_proxy", Type: cty.Bool, Required: false},
-		"winrm_port":                   &hcldec.AttrSpec{Name: "winrm_port", Type: cty.Number, Required: false},
-		"winrm_timeout":                &hcldec.AttrSpec{Name: "winrm_timeout", Type: cty.String, Required: false},
-		"winrm_use_ssl":                &hcldec.AttrSpec{Name: "winrm_use_ssl", Type: cty.Bool, Required: false},
-		"winrm_insecure":               &hcldec.AttrSpec{Name: "winrm_insecure", Type: cty.Bool, Required: false},
-		"winrm_use_ntlm":               &hcldec.AttrSpec{Name: "winrm_use_ntlm", Type: cty.Bool, Required: false},
-		"username":                     &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
-		"password":                     &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
-		"url":                          &hcldec.AttrSpec{Name: "url", Type: cty.String, Required: false},
-		"location":                     &hcldec.AttrSpec{Name: "location", Type: cty.String, Required: false},
-		"image":                        &hcldec.AttrSpec{Name: "image", Type: cty.String, Required: false},
-		"snapshot_name":                &hcldec.AttrSpec{Name: "snapshot_name", Type: cty.String, Required: false},
-		"disk_size":                    &hcldec.AttrSpec{Name: "disk_size", Type: cty.Number, Required: false},
-		"disk_type":                    &hcldec.AttrSpec{Name: "disk_type", Type: cty.String, Required: false},
-		"cores":                        &hcldec.AttrSpec{Name: "cores", Type: cty.Number, Required: false},
-		"ram":                          &hcldec.AttrSpec{Name: "ram", Type: cty.Number, Required: false},
-		"retries":                      &hcldec.AttrSpec{Name: "retries", Type: cty.Number, Required: false},
+		"packer_build_name":               &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":             &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":             &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":                    &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":                    &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":                 &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":           &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables":      &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"communicator":                    &hcldec.AttrSpec{Name: "communicator", Type: cty.String, Required: false},
+		"pause_before_connecting":         &hcldec.AttrSpec{Name: "pause_before_connecting", Type: cty.String, Required: false},
+		"ssh_host":                        &hcldec.AttrSpec{Name: "ssh_host", Type: cty.String, Required: false},
+		"ssh_port":                        &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
+		"ssh_username":                    &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
+		"ssh_password":                    &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
+		"ssh_keypair_name":                &hcldec.AttrSpec{Name: "ssh_keypair_name", Type: cty.String, Required: false},
+		"temporary_key_pair_name":         &hcldec.AttrSpec{Name: "temporary_key_pair_name", Type: cty.String, Required: false},
+		"temporary_key_pair_type":         &hcldec.AttrSpec{Name: "temporary_key_pair_type", Type: cty.String, Required: false},
+		"temporary_key_pair_bits":         &hcldec.AttrSpec{Name: "temporary_key_pair_bits", Type: cty.Number, Required: false},
+		"ssh_ciphers":                     &hcldec.AttrSpec{Name: "ssh_ciphers", Type: cty.List(cty.String), Required: false},
+		"ssh_clear_authorized_keys":       &hcldec.AttrSpec{Name: "ssh_clear_authorized_keys", Type: cty.Bool, Required: false},
+		"ssh_key_exchange_algorithms":     &hcldec.AttrSpec{Name: "ssh_key_exchange_algorithms", Type: cty.List(cty.String), Required: false},
+		"ssh_private_key_file":            &hcldec.AttrSpec{Name: "ssh_private_key_file", Type: cty.String, Required: false},
+		"ssh_certificate_file":            &hcldec.AttrSpec{Name: "ssh_certificate_file", Type: cty.String, Required: false},
+		"ssh_pty":                         &hcldec.AttrSpec{Name: "ssh_pty", Type: cty.Bool, Required: false},
+		"ssh_timeout":                     &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
+		"ssh_wait_timeout":                &hcldec.AttrSpec{Name: "ssh_wait_timeout", Type: cty.String, Required: false},
+		"ssh_agent_auth":                  &hcldec.AttrSpec{Name: "ssh_agent_auth", Type: cty.Bool, Required: false},
+		"ssh_disable_agent_forwarding":    &hcldec.AttrSpec{Name: "ssh_disable_agent_forwarding", Type: cty.Bool, Required: false},
+		"ssh_handshake_attempts":          &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
+		"ssh_bastion_host":                &hcldec.AttrSpec{Name: "ssh_bastion_host", Type: cty.String, Required: false},
+		"ssh_bastion_port":                &hcldec.AttrSpec{Name: "ssh_bastion_port", Type: cty.Number, Required: false},
+		"ssh_bastion_agent_auth":          &hcldec.AttrSpec{Name: "ssh_bastion_agent_auth", Type: cty.Bool, Required: false},
+		"ssh_bastion_username":            &hcldec.AttrSpec{Name: "ssh_bastion_username", Type: cty.String, Required: false},
+		"ssh_bastion_password":            &hcldec.AttrSpec{Name: "ssh_bastion_password", Type: cty.String, Required: false},
+		"ssh_bastion_interactive":         &hcldec.AttrSpec{Name: "ssh_bastion_interactive", Type: cty.Bool, Required: false},
+		"ssh_bastion_private_key_file":    &hcldec.AttrSpec{Name: "ssh_bastion_private_key_file", Type: cty.String, Required: false},
+		"ssh_bastion_certificate_file":    &hcldec.AttrSpec{Name: "ssh_bastion_certificate_file", Type: cty.String, Required: false},
+		"ssh_file_transfer_method":        &hcldec.AttrSpec{Name: "ssh_file_transfer_method", Type: cty.String, Required: false},
+		"ssh_proxy_host":                  &hcldec.AttrSpec{Name: "ssh_proxy_host", Type: cty.String, Required: false},
+		"ssh_proxy_port":                  &hcldec.AttrSpec{Name: "ssh_proxy_port", Type: cty.Number, Required: false},
+		"ssh_proxy_username":              &hcldec.AttrSpec{Name: "ssh_proxy_username", Type: cty.String, Required: false},
+		"ssh_proxy_password":              &hcldec.AttrSpec{Name: "ssh_proxy_password", Type: cty.String, Required: false},
+		"ssh_keep_alive_interval":         &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
+		"ssh_read_write_timeout":          &hcldec.AttrSpec{Name: "ssh_read_write_timeout", Type: cty.String, Required: false},
+		"ssh_remote_tunnels":              &hcldec.AttrSpec{Name: "ssh_remote_tunnels", Type: cty.List(cty.String), Required: false},
+		"ssh_local_tunnels":               &hcldec.AttrSpec{Name: "ssh_local_tunnels", Type: cty.List(cty.String), Required: false},
+		"ssh_public_key":                  &hcldec.AttrSpec{Name: "ssh_public_key", Type: cty.List(cty.Number), Required: false},
+		"ssh_private_key":                 &hcldec.AttrSpec{Name: "ssh_private_key", Type: cty.List(cty.Number), Required: false},
+		"winrm_username":                  &hcldec.AttrSpec{Name: "winrm_username", Type: cty.String, Required: false},
+		"winrm_password":                  &hcldec.AttrSpec{Name: "winrm_password", Type: cty.String, Required: false},
+		"winrm_host":                      &hcldec.AttrSpec{Name: "winrm_host", Type: cty.String, Required: false},
+		"winrm_no_proxy":                  &hcldec.AttrSpec{Name: "winrm_no_proxy", Type: cty.Bool, Required: false},
+		"winrm_port":                      &hcldec.AttrSpec{Name: "winrm_port", Type: cty.Number, Required: false},
+		"winrm_timeout":                   &hcldec.AttrSpec{Name: "winrm_timeout", Type: cty.String, Required: false},
+		"winrm_use_ssl":                   &hcldec.AttrSpec{Name: "winrm_use_ssl", Type: cty.Bool, Required: false},
+		"winrm_insecure":                  &hcldec.AttrSpec{Name: "winrm_insecure", Type: cty.Bool, Required: false},
+		"winrm_use_ntlm":                  &hcldec.AttrSpec{Name: "winrm_use_ntlm", Type: cty.Bool, Required: false},
+		"username":                        &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
+		"password":                        &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
+		"token":                           &hcldec.AttrSpec{Name: "token", Type: cty.String, Required: false},
+		"url":                             &hcldec.AttrSpec{Name: "url", Type: cty.String, Required: false},
+		"profile":                         &hcldec.AttrSpec{Name: "profile", Type: cty.String, Required: false},
+		"credentials_file":                &hcldec.AttrSpec{Name: "credentials_file", Type: cty.String, Required: false},
+		"retries":                         &hcldec.AttrSpec{Name: "retries", Type: cty.Number, Required: false},
+		"retry_wait_min":                  &hcldec.AttrSpec{Name: "retry_wait_min", Type: cty.String, Required: false},
+		"retry_wait_max":                  &hcldec.AttrSpec{Name: "retry_wait_max", Type: cty.String, Required: false},
+		"location":                        &hcldec.AttrSpec{Name: "location", Type: cty.String, Required: false},
+		"image":                           &hcldec.AttrSpec{Name: "image", Type: cty.String, Required: false},
+		"snapshot_name":                   &hcldec.AttrSpec{Name: "snapshot_name", Type: cty.String, Required: false},
+		"disk_size":                       &hcldec.AttrSpec{Name: "disk_size", Type: cty.Number, Required: false},
+		"disk_type":                       &hcldec.AttrSpec{Name: "disk_type", Type: cty.String, Required: false},
+		"cores":                           &hcldec.AttrSpec{Name: "cores", Type: cty.Number, Required: false},
+		"ram":                             &hcldec.AttrSpec{Name: "ram", Type: cty.Number, Required: false},
+		"server_type":                     &hcldec.AttrSpec{Name: "server_type", Type: cty.String, Required: false},
+		"template_uuid":                   &hcldec.AttrSpec{Name: "template_uuid", Type: cty.String, Required: false},
+		"template_name":                   &hcldec.AttrSpec{Name: "template_name", Type: cty.String, Required: false},
+		"cpu_family":                      &hcldec.AttrSpec{Name: "cpu_family", Type: cty.String, Required: false},
+		"availability_zone":               &hcldec.AttrSpec{Name: "availability_zone", Type: cty.String, Required: false},
+		"image_alias":                     &hcldec.AttrSpec{Name: "image_alias", Type: cty.String, Required: false},
+		"image_match":                     &hcldec.AttrSpec{Name: "image_match", Type: cty.String, Required: false},
+		"image_most_recent":               &hcldec.AttrSpec{Name: "image_most_recent", Type: cty.Bool, Required: false},
+		"image_visibility":                &hcldec.AttrSpec{Name: "image_visibility", Type: cty.String, Required: false},
+		"source_snapshot":                 &hcldec.AttrSpec{Name: "source_snapshot", Type: cty.String, Required: false},
+		"image_filter":                    &hcldec.BlockSpec{TypeName: "image_filter", Nested: hcldec.ObjectSpec((*FlatImageFilter)(nil).HCL2Spec())},
+		"datacenter_id":                   &hcldec.AttrSpec{Name: "datacenter_id", Type: cty.String, Required: false},
+		"datacenter_name":                 &hcldec.AttrSpec{Name: "datacenter_name", Type: cty.String, Required: false},
+		"lan_id":                          &hcldec.AttrSpec{Name: "lan_id", Type: cty.String, Required: false},
+		"lan_name":                        &hcldec.AttrSpec{Name: "lan_name", Type: cty.String, Required: false},
+		"reserve_ip":                      &hcldec.AttrSpec{Name: "reserve_ip", Type: cty.Bool, Required: false},
+		"ip_block_id":                     &hcldec.AttrSpec{Name: "ip_block_id", Type: cty.String, Required: false},
+		"firewall_active":                 &hcldec.AttrSpec{Name: "firewall_active", Type: cty.Bool, Required: false},
+		"firewall_rule":                   &hcldec.BlockListSpec{TypeName: "firewall_rule", Nested: hcldec.ObjectSpec((*FlatFirewallRule)(nil).HCL2Spec())},
+		"firewall_source_cidrs":           &hcldec.AttrSpec{Name: "firewall_source_cidrs", Type: cty.List(cty.String), Required: false},
+		"firewall_source_public_ip":       &hcldec.AttrSpec{Name: "firewall_source_public_ip", Type: cty.Bool, Required: false},
+		"nic":                             &hcldec.BlockListSpec{TypeName: "nic", Nested: hcldec.ObjectSpec((*FlatNic)(nil).HCL2Spec())},
+		"communicator_nic":                &hcldec.AttrSpec{Name: "communicator_nic", Type: cty.String, Required: false},
+		"volume":                          &hcldec.BlockListSpec{TypeName: "volume", Nested: hcldec.ObjectSpec((*FlatVolume)(nil).HCL2Spec())},
+		"snapshot_description":            &hcldec.AttrSpec{Name: "snapshot_description", Type: cty.String, Required: false},
+		"snapshot_licence_type":           &hcldec.AttrSpec{Name: "snapshot_licence_type", Type: cty.String, Required: false},
+		"snapshot_sec_auth_protection":    &hcldec.AttrSpec{Name: "snapshot_sec_auth_protection", Type: cty.Bool, Required: false},
+		"snapshot_cpu_hot_plug":           &hcldec.AttrSpec{Name: "snapshot_cpu_hot_plug", Type: cty.Bool, Required: false},
+		"snapshot_cpu_hot_unplug":         &hcldec.AttrSpec{Name: "snapshot_cpu_hot_unplug", Type: cty.Bool, Required: false},
+		"snapshot_ram_hot_plug":           &hcldec.AttrSpec{Name: "snapshot_ram_hot_plug", Type: cty.Bool, Required: false},
+		"snapshot_ram_hot_unplug":         &hcldec.AttrSpec{Name: "snapshot_ram_hot_unplug", Type: cty.Bool, Required: false},
+		"snapshot_nic_hot_plug":           &hcldec.AttrSpec{Name: "snapshot_nic_hot_plug", Type: cty.Bool, Required: false},
+		"snapshot_nic_hot_unplug":         &hcldec.AttrSpec{Name: "snapshot_nic_hot_unplug", Type: cty.Bool, Required: false},
+		"snapshot_disc_virtio_hot_plug":   &hcldec.AttrSpec{Name: "snapshot_disc_virtio_hot_plug", Type: cty.Bool, Required: false},
+		"snapshot_disc_virtio_hot_unplug": &hcldec.AttrSpec{Name: "snapshot_disc_virtio_hot_unplug", Type: cty.Bool, Required: false},
+		"snapshot_disc_scsi_hot_plug":     &hcldec.AttrSpec{Name: "snapshot_disc_scsi_hot_plug", Type: cty.Bool, Required: false},
+		"snapshot_disc_scsi_hot_unplug":   &hcldec.AttrSpec{Name: "snapshot_disc_scsi_hot_unplug", Type: cty.Bool, Required: false},
+		"force_delete_snapshot":           &hcldec.AttrSpec{Name: "force_delete_snapshot", Type: cty.Bool, Required: false},
+		"skip_if_exists":                  &hcldec.AttrSpec{Name: "skip_if_exists", Type: cty.Bool, Required: false},
+		"skip_create_snapshot":            &hcldec.AttrSpec{Name: "skip_create_snapshot", Type: cty.Bool, Required: false},
+		"shutdown_behavior":               &hcldec.AttrSpec{Name: "shutdown_behavior", Type: cty.String, Required: false},
+		"shutdown_command":                &hcldec.AttrSpec{Name: "shutdown_command", Type: cty.String, Required: false},
+		"shutdown_timeout":                &hcldec.AttrSpec{Name: "shutdown_timeout", Type: cty.String, Required: false},
+		"snapshot_labels":                 &hcldec.AttrSpec{Name: "snapshot_labels", Type: cty.Map(cty.String), Required: false},
+		"run_labels":                      &hcldec.AttrSpec{Name: "run_labels", Type: cty.Map(cty.String), Required: false},
+		"check_quota":                     &hcldec.AttrSpec{Name: "check_quota", Type: cty.Bool, Required: false},
+		"quota_wait_timeout":              &hcldec.AttrSpec{Name: "quota_wait_timeout", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatFirewallRule is an auto-generated flat version of FirewallRule.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatFirewallRule struct {
+	Name           *string `mapstructure:"name" cty:"name" hcl:"name"`
+	Protocol       *string `mapstructure:"protocol" cty:"protocol" hcl:"protocol"`
+	SourceIp       *string `mapstructure:"source_ip" cty:"source_ip" hcl:"source_ip"`
+	TargetIp       *string `mapstructure:"target_ip" cty:"target_ip" hcl:"target_ip"`
+	PortRangeStart *int32  `mapstructure:"port_range_start" cty:"port_range_start" hcl:"port_range_start"`
+	PortRangeEnd   *int32  `mapstructure:"port_range_end" cty:"port_range_end" hcl:"port_range_end"`
+	Type           *string `mapstructure:"type" cty:"type" hcl:"type"`
+}
+
+// FlatMapstructure returns a new FlatFirewallRule.
+// FlatFirewallRule is an auto-generated flat version of FirewallRule.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*FirewallRule) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatFirewallRule)
+}
+
+// HCL2Spec returns the hcl spec of a FirewallRule.
+// This spec is used by HCL to read the fields of FirewallRule.
+// The decoded values from this spec will then be applied to a FlatFirewallRule.
+func (*FlatFirewallRule) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":             &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"protocol":         &hcldec.AttrSpec{Name: "protocol", Type: cty.String, Required: false},
+		"source_ip":        &hcldec.AttrSpec{Name: "source_ip", Type: cty.String, Required: false},
+		"target_ip":        &hcldec.AttrSpec{Name: "target_ip", Type: cty.String, Required: false},
+		"port_range_start": &hcldec.AttrSpec{Name: "port_range_start", Type: cty.Number, Required: false},
+		"port_range_end":   &hcldec.AttrSpec{Name: "port_range_end", Type: cty.Number, Required: false},
+		"type":             &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatImageFilter is an auto-generated flat version of ImageFilter.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatImageFilter struct {
+	Name        *string           `mapstructure:"name" cty:"name" hcl:"name"`
+	LicenceType *string           `mapstructure:"licence_type" cty:"licence_type" hcl:"licence_type"`
+	ImageType   *string           `mapstructure:"image_type" cty:"image_type" hcl:"image_type"`
+	Location    *string           `mapstructure:"location" cty:"location" hcl:"location"`
+	Visibility  *string           `mapstructure:"visibility" cty:"visibility" hcl:"visibility"`
+	CloudInit   *string           `mapstructure:"cloud_init" cty:"cloud_init" hcl:"cloud_init"`
+	Labels      map[string]string `mapstructure:"labels" cty:"labels" hcl:"labels"`
+	MostRecent  *bool             `mapstructure:"most_recent" cty:"most_recent" hcl:"most_recent"`
+}
+
+// FlatMapstructure returns a new FlatImageFilter.
+// FlatImageFilter is an auto-generated flat version of ImageFilter.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*ImageFilter) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatImageFilter)
+}
+
+// HCL2Spec returns the hcl spec of a ImageFilter.
+// This spec is used by HCL to read the fields of ImageFilter.
+// The decoded values from this spec will then be applied to a FlatImageFilter.
+func (*FlatImageFilter) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":         &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"licence_type": &hcldec.AttrSpec{Name: "licence_type", Type: cty.String, Required: false},
+		"image_type":   &hcldec.AttrSpec{Name: "image_type", Type: cty.String, Required: false},
+		"location":     &hcldec.AttrSpec{Name: "location", Type: cty.String, Required: false},
+		"visibility":   &hcldec.AttrSpec{Name: "visibility", Type: cty.String, Required: false},
+		"cloud_init":   &hcldec.AttrSpec{Name: "cloud_init", Type: cty.String, Required: false},
+		"labels":       &hcldec.AttrSpec{Name: "labels", Type: cty.Map(cty.String), Required: false},
+		"most_recent":  &hcldec.AttrSpec{Name: "most_recent", Type: cty.Bool, Required: false},
+	}
+	return s
+}
+
+// FlatNic is an auto-generated flat version of Nic.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatNic struct {
+	Name           *string  `mapstructure:"name" cty:"name" hcl:"name"`
+	LanId          *string  `mapstructure:"lan_id" cty:"lan_id" hcl:"lan_id"`
+	LanName        *string  `mapstructure:"lan_name" cty:"lan_name" hcl:"lan_name"`
+	Dhcp           *bool    `mapstructure:"dhcp" cty:"dhcp" hcl:"dhcp"`
+	Ips            []string `mapstructure:"ips" cty:"ips" hcl:"ips"`
+	Dhcpv6         *bool    `mapstructure:"dhcpv6" cty:"dhcpv6" hcl:"dhcpv6"`
+	Ipv6Ips        []string `mapstructure:"ipv6_ips" cty:"ipv6_ips" hcl:"ipv6_ips"`
+	FirewallActive *bool    `mapstructure:"firewall_active" cty:"firewall_active" hcl:"firewall_active"`
+}
+
+// FlatMapstructure returns a new FlatNic.
+// FlatNic is an auto-generated flat version of Nic.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Nic) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatNic)
+}
+
+// HCL2Spec returns the hcl spec of a Nic.
+// This spec is used by HCL to read the fields of Nic.
+// The decoded values from this spec will then be applied to a FlatNic.
+func (*FlatNic) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":            &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"lan_id":          &hcldec.AttrSpec{Name: "lan_id", Type: cty.String, Required: false},
+		"lan_name":        &hcldec.AttrSpec{Name: "lan_name", Type: cty.String, Required: false},
+		"dhcp":            &hcldec.AttrSpec{Name: "dhcp", Type: cty.Bool, Required: false},
+		"ips":             &hcldec.AttrSpec{Name: "ips", Type: cty.List(cty.String), Required: false},
+		"dhcpv6":          &hcldec.AttrSpec{Name: "dhcpv6", Type: cty.Bool, Required: false},
+		"ipv6_ips":        &hcldec.AttrSpec{Name: "ipv6_ips", Type: cty.List(cty.String), Required: false},
+		"firewall_active": &hcldec.AttrSpec{Name: "firewall_active", Type: cty.Bool, Required: false},
+	}
+	return s
+}
+
+// FlatVolume is an auto-generated flat version of Volume.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatVolume struct {
+	Name         *string  `mapstructure:"name" cty:"name" hcl:"name"`
+	Size         *float32 `mapstructure:"size" cty:"size" hcl:"size"`
+	Type         *string  `mapstructure:"type" cty:"type" hcl:"type"`
+	Bus          *string  `mapstructure:"bus" cty:"bus" hcl:"bus"`
+	Image        *string  `mapstructure:"image" cty:"image" hcl:"image"`
+	Snapshot     *bool    `mapstructure:"snapshot" cty:"snapshot" hcl:"snapshot"`
+	SnapshotName *string  `mapstructure:"snapshot_name" cty:"snapshot_name" hcl:"snapshot_name"`
+}
+
+// FlatMapstructure returns a new FlatVolume.
+// FlatVolume is an auto-generated flat version of Volume.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Volume) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatVolume)
+}
+
+// HCL2Spec returns the hcl spec of a Volume.
+// This spec is used by HCL to read the fields of Volume.
+// The decoded values from this spec will then be applied to a FlatVolume.
+func (*FlatVolume) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":          &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"size":          &hcldec.AttrSpec{Name: "size", Type: cty.Number, Required: false},
+		"type":          &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"bus":           &hcldec.AttrSpec{Name: "bus", Type: cty.String, Required: false},
+		"image":         &hcldec.AttrSpec{Name: "image", Type: cty.String, Required: false},
+		"snapshot":      &hcldec.AttrSpec{Name: "snapshot", Type: cty.Bool, Required: false},
+		"snapshot_name": &hcldec.AttrSpec{Name: "snapshot_name", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultProfileName = "default"
+
+// credentialsProfile holds the values of one named section of the local
+// credentials file.
+type credentialsProfile struct {
+	Username string
+	Password string
+	Token    string
+	Url      string
+}
+
+// defaultCredentialsFile returns ~/.ionos/credentials, or an empty string if
+// the home directory cannot be determined.
+func defaultCredentialsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ionos", "credentials")
+}
+
+// loadProfile reads the profile called name from an INI style credentials
+// file:
+//
+//	[default]
+//	token = ...
+//
+//	[staging]
+//	username = ...
+//	password = ...
+//	url      = https://api.example.com
+//
+// It returns nil and no error if the profile is not present in the file.
+func loadProfile(path, name string) (*credentialsProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var profile *credentialsProfile
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			if section == name && profile == nil {
+				profile = &credentialsProfile{}
+			}
+			continue
+		}
+		if section != name {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected 'key = value'", path, line)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "username":
+			profile.Username = value
+		case "password":
+			profile.Password = value
+		case "token":
+			profile.Token = value
+		case "url":
+			profile.Url = value
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown key %q", path, line, strings.TrimSpace(key))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
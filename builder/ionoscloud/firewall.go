@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// publicIpUrl answers with the public IP address of the caller in plain
+// text.
+var publicIpUrl = "https://api.ipify.org"
+
+// detectPublicIp returns the public IP address the host running Packer
+// connects from.
+func detectPublicIp(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, publicIpUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error detecting public IP: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error detecting public IP: %s returned %s", publicIpUrl, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("error detecting public IP: %w", err)
+	}
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("error detecting public IP: %q is not an IP address", ip)
+	}
+	return ip, nil
+}
+
+// firewallRules returns the configured firewall rules, followed by one rule
+// per allowed source for the communicator port if communicator is set.
+// publicIp is added to the sources if it is not empty.
+func firewallRules(c *Config, communicator bool, publicIp string) []ionoscloud.FirewallRule {
+	rules := make([]ionoscloud.FirewallRule, 0, len(c.FirewallRules))
+	for _, r := range c.FirewallRules {
+		props := &ionoscloud.FirewallruleProperties{
+			Protocol: ionoscloud.PtrString(r.Protocol),
+			Type:     ionoscloud.PtrString(r.Type),
+		}
+		if r.Name != "" {
+			props.Name = ionoscloud.PtrString(r.Name)
+		}
+		if r.SourceIp != "" {
+			props.SourceIp = ionoscloud.PtrString(r.SourceIp)
+		}
+		if r.TargetIp != "" {
+			props.TargetIp = ionoscloud.PtrString(r.TargetIp)
+		}
+		if r.PortRangeStart != 0 {
+			props.PortRangeStart = ionoscloud.PtrInt32(r.PortRangeStart)
+			props.PortRangeEnd = ionoscloud.PtrInt32(r.PortRangeEnd)
+		}
+		rules = append(rules, ionoscloud.FirewallRule{Properties: props})
+	}
+
+	if !communicator || c.Comm.Type == "none" {
+		return rules
+	}
+	sources := append([]string(nil), c.FirewallSourceCidrs...)
+	if publicIp != "" {
+		sources = append(sources, publicIp)
+	}
+	port := int32(c.Comm.Port())
+	for _, source := range sources {
+		rules = append(rules, ionoscloud.FirewallRule{
+			Properties: &ionoscloud.FirewallruleProperties{
+				Name:           ionoscloud.PtrString(fmt.Sprintf("packer-%s", c.Comm.Type)),
+				Protocol:       ionoscloud.PtrString("TCP"),
+				Type:           ionoscloud.PtrString("INGRESS"),
+				SourceIp:       ionoscloud.PtrString(source),
+				PortRangeStart: ionoscloud.PtrInt32(port),
+				PortRangeEnd:   ionoscloud.PtrInt32(port),
+			},
+		})
+	}
+	return rules
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectPublicIp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("198.51.100.7\n"))
+	}))
+	defer srv.Close()
+
+	defer func(url string) { publicIpUrl = url }(publicIpUrl)
+	publicIpUrl = srv.URL
+
+	ip, err := detectPublicIp(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ip != "198.51.100.7" {
+		t.Fatalf("bad ip: %q", ip)
+	}
+}
+
+func TestFirewallRules(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["firewall_active"] = true
+	config["firewall_source_cidrs"] = []string{"192.0.2.0/24"}
+	config["firewall_rule"] = []map[string]interface{}{
+		{"protocol": "icmp"},
+	}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	rules := firewallRules(&b.config, true, "198.51.100.7")
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rules))
+	}
+	if p := rules[0].Properties; *p.Protocol != "ICMP" || *p.Type != "INGRESS" {
+		t.Fatalf("bad configured rule: %s %s", *p.Protocol, *p.Type)
+	}
+	for i, source := range []string{"192.0.2.0/24", "198.51.100.7"} {
+		p := rules[i+1].Properties
+		if *p.SourceIp != source || *p.Protocol != "TCP" || *p.PortRangeStart != 22 {
+			t.Fatalf("bad communicator rule for %s: %s %s %d", source, *p.SourceIp, *p.Protocol, *p.PortRangeStart)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+const (
+	imageMatchContains = "contains"
+	imageMatchExact    = "exact"
+	imageMatchPrefix   = "prefix"
+	imageMatchRegex    = "regex"
+)
+
+const (
+	imageVisibilityPublic  = "public"
+	imageVisibilityPrivate = "private"
+	imageVisibilityAny     = "any"
+)
+
+const (
+	sourceTypeImage    = "image"
+	sourceTypeAlias    = "image_alias"
+	sourceTypeSnapshot = "snapshot"
+)
+
+// sourceImage is what the build volume is created from. Either Id or Alias
+// is set, Name is informational only.
+type sourceImage struct {
+	Id    string
+	Alias string
+	Name  string
+	// Type is one of the sourceType constants.
+	Type string
+	// Public images accept an image password and SSH keys on volume
+	// creation, private images and snapshots do not.
+	Public bool
+	// Location, ImageType and Size are not known for image aliases.
+	Location  string
+	ImageType string
+	Size      float32
+}
+
+// ImageResource is the common view of an image or a snapshot used to match
+// them against the configured source.
+type ImageResource struct {
+	Id          string
+	Name        string
+	Location    string
+	ImageType   string
+	LicenceType string
+	CloudInit   string
+	Public      bool
+	Created     time.Time
+	// Size is in GB.
+	Size float32
+	// Labels are only filled in by attachLabels.
+	Labels map[string]string
+}
+
+// imageQuery describes which images or snapshots are acceptable.
+type imageQuery struct {
+	// Name is compared to the resource names according to Match.
+	Name       string
+	Match      string
+	Location   string
+	Visibility string
+	MostRecent bool
+	// ImageType defaults to HDD, the only type a volume can be created from.
+	ImageType   string
+	LicenceType string
+	CloudInit   string
+	Labels      map[string]string
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// isUUID reports whether s is an IONOS resource ID rather than a name.
+func isUUID(s string) bool {
+	return uuidRegexp.MatchString(s)
+}
+
+// newNameMatcher returns a func that reports whether a name matches pattern
+// according to mode. All modes except regex compare case-insensitively.
+func newNameMatcher(pattern, mode string) (func(string) bool, error) {
+	lower := strings.ToLower(pattern)
+	switch mode {
+	case imageMatchContains, "":
+		return func(name string) bool {
+			return strings.Contains(strings.ToLower(name), lower)
+		}, nil
+	case imageMatchExact:
+		return func(name string) bool {
+			return strings.EqualFold(name, pattern)
+		}, nil
+	case imageMatchPrefix:
+		return func(name string) bool {
+			return strings.HasPrefix(strings.ToLower(name), lower)
+		}, nil
+	case imageMatchRegex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+	return nil, fmt.Errorf("unknown match mode %q, must be one of %s, %s, %s or %s",
+		mode, imageMatchContains, imageMatchExact, imageMatchPrefix, imageMatchRegex)
+}
+
+// checkImageAlias fails if alias is not offered by location, which is
+// named name.
+func checkImageAlias(location ionoscloud.Location, name, alias string) error {
+	var aliases []string
+	if location.Properties != nil && location.Properties.ImageAliases != nil {
+		aliases = *location.Properties.ImageAliases
+	}
+	for _, a := range aliases {
+		if a == alias {
+			return nil
+		}
+	}
+	sorted := append([]string(nil), aliases...)
+	sort.Strings(sorted)
+	return fmt.Errorf("image alias %q is not available in %s, available aliases: %s",
+		alias, name, strings.Join(sorted, ", "))
+}
+
+// imageResourceFromImage converts an image returned by the Images API.
+func imageResourceFromImage(img ionoscloud.Image) ImageResource {
+	r := ImageResource{Id: stringValue(img.Id)}
+	if props := img.Properties; props != nil {
+		r.Name = stringValue(props.Name)
+		r.Location = stringValue(props.Location)
+		r.ImageType = stringValue(props.ImageType)
+		r.LicenceType = stringValue(props.LicenceType)
+		r.CloudInit = stringValue(props.CloudInit)
+		r.Public = props.Public != nil && *props.Public
+		if props.Size != nil {
+			r.Size = *props.Size
+		}
+	}
+	if img.Metadata != nil && img.Metadata.CreatedDate != nil {
+		r.Created = img.Metadata.CreatedDate.Time
+	}
+	return r
+}
+
+// imageResourceFromSnapshot converts a snapshot returned by the Snapshots
+// API. Snapshots are always private and can only back HDD volumes.
+func imageResourceFromSnapshot(snapshot ionoscloud.Snapshot) ImageResource {
+	r := ImageResource{Id: stringValue(snapshot.Id), ImageType: "HDD"}
+	if props := snapshot.Properties; props != nil {
+		r.Name = stringValue(props.Name)
+		r.Location = stringValue(props.Location)
+		r.LicenceType = stringValue(props.LicenceType)
+		if props.Size != nil {
+			r.Size = *props.Size
+		}
+	}
+	if snapshot.Metadata != nil && snapshot.Metadata.CreatedDate != nil {
+		r.Created = snapshot.Metadata.CreatedDate.Time
+	}
+	return r
+}
+
+// imageQuery returns the query for an image or snapshot called name in the
+// build location.
+func (c *Config) imageQuery(name string) imageQuery {
+	return imageQuery{
+		Name:       name,
+		Match:      c.ImageMatch,
+		Location:   c.Region,
+		Visibility: c.ImageVisibility,
+		MostRecent: c.ImageMostRecent,
+	}
+}
+
+// query returns the query described by the image_filter block. The location
+// defaults to the build location.
+func (f *ImageFilter) query(location string) imageQuery {
+	q := imageQuery{
+		Name:        f.Name,
+		Match:       imageMatchRegex,
+		Location:    f.Location,
+		Visibility:  f.Visibility,
+		MostRecent:  f.MostRecent,
+		ImageType:   f.ImageType,
+		LicenceType: f.LicenceType,
+		CloudInit:   f.CloudInit,
+		Labels:      f.Labels,
+	}
+	if q.Location == "" {
+		q.Location = location
+	}
+	return q
+}
+
+// query returns the query described by the snapshot filter. Name is matched
+// exactly, NameRegex as a regular expression.
+func (f *SnapshotFilter) query() imageQuery {
+	q := imageQuery{
+		Name:       f.NameRegex,
+		Match:      imageMatchRegex,
+		Location:   f.Location,
+		Visibility: imageVisibilityAny,
+		MostRecent: f.MostRecent,
+		Labels:     f.Labels,
+	}
+	if f.Name != "" {
+		q.Name = f.Name
+		q.Match = imageMatchExact
+	}
+	return q
+}
+
+// find returns the single HDD resource in the query location whose name
+// matches the query. kind names the resources in error messages. It fails if
+// nothing matches, and if several resources match unless MostRecent asks for
+// the newest of them.
+func (q imageQuery) find(resources []ImageResource, kind string) (*ImageResource, error) {
+	match, err := newNameMatcher(q.Name, q.Match)
+	if err != nil {
+		return nil, err
+	}
+
+	imageType := q.ImageType
+	if imageType == "" {
+		imageType = "HDD"
+	}
+
+	var available, candidates []ImageResource
+	for _, r := range resources {
+		if r.Id == "" || r.Name == "" || !strings.EqualFold(r.ImageType, imageType) || r.Location != q.Location {
+			continue
+		}
+		if q.Visibility == imageVisibilityPublic && !r.Public ||
+			q.Visibility == imageVisibilityPrivate && r.Public {
+			continue
+		}
+		if q.LicenceType != "" && !strings.EqualFold(r.LicenceType, q.LicenceType) ||
+			q.CloudInit != "" && !strings.EqualFold(r.CloudInit, q.CloudInit) {
+			continue
+		}
+		available = append(available, r)
+		if match(r.Name) && hasLabels(r, q.Labels) {
+			candidates = append(candidates, r)
+		}
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return nil, fmt.Errorf("no %s in %s matches %s, available: %s",
+			kind, q.Location, q, imageResourceNames(available))
+	case len(candidates) == 1:
+		return &candidates[0], nil
+	case !q.MostRecent:
+		return nil, fmt.Errorf("%d %ss in %s match %s, narrow down the match or select the most recent: %s",
+			len(candidates), kind, q.Location, q, imageResourceNames(candidates))
+	}
+
+	sortImageResourcesByCreation(candidates)
+	return &candidates[0], nil
+}
+
+// String describes the name and filter criteria of the query for error
+// messages.
+func (q imageQuery) String() string {
+	criteria := []string{fmt.Sprintf("name %q (%s)", q.Name, q.Match)}
+	if q.ImageType != "" {
+		criteria = append(criteria, "image type "+q.ImageType)
+	}
+	if q.LicenceType != "" {
+		criteria = append(criteria, "licence type "+q.LicenceType)
+	}
+	if q.CloudInit != "" {
+		criteria = append(criteria, "cloud-init "+q.CloudInit)
+	}
+	if q.Visibility != "" && q.Visibility != imageVisibilityAny {
+		criteria = append(criteria, q.Visibility)
+	}
+	keys := make([]string, 0, len(q.Labels))
+	for k := range q.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		criteria = append(criteria, fmt.Sprintf("label %s=%s", k, q.Labels[k]))
+	}
+	return strings.Join(criteria, ", ")
+}
+
+// hasLabels reports whether r carries all of labels.
+func hasLabels(r ImageResource, labels map[string]string) bool {
+	for k, v := range labels {
+		if r.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// FindImage returns the single image matching filter, or the most recent one
+// if the filter asks for it. The filter location must be set.
+func FindImage(ctx context.Context, client *ionoscloud.APIClient, filter ImageFilter) (*ImageResource, error) {
+	return findImage(ctx, client, filter.query(filter.Location))
+}
+
+// findImage looks up the image described by q through the Images API. Only
+// the images in the query location are requested.
+func findImage(ctx context.Context, client *ionoscloud.APIClient, q imageQuery) (*ImageResource, error) {
+	images, resp, err := client.ImagesApi.ImagesGet(ctx).Filter("location", q.Location).Execute()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode > 299 {
+		return nil, errors.New("error occurred while getting images")
+	}
+	if images.Items == nil {
+		return nil, errors.New("no images returned by the API")
+	}
+	resources := make([]ImageResource, 0, len(*images.Items))
+	for _, img := range *images.Items {
+		resources = append(resources, imageResourceFromImage(img))
+	}
+	if len(q.Labels) > 0 {
+		if err := attachLabels(ctx, client, resources); err != nil {
+			return nil, err
+		}
+	}
+	return q.find(resources, "image")
+}
+
+// FindSnapshot returns the single snapshot matching filter, or the most
+// recent one if the filter asks for it.
+func FindSnapshot(ctx context.Context, client *ionoscloud.APIClient, filter SnapshotFilter) (*ImageResource, error) {
+	return findSnapshot(ctx, client, filter.query())
+}
+
+// findSnapshot looks up the snapshot described by q through the Snapshots
+// API. Snapshots are always private, so the query visibility is ignored.
+func findSnapshot(ctx context.Context, client *ionoscloud.APIClient, q imageQuery) (*ImageResource, error) {
+	snapshots, _, err := client.SnapshotsApi.SnapshotsGet(ctx).Filter("location", q.Location).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("error getting snapshots: %w", err)
+	}
+	if snapshots.Items == nil {
+		return nil, errors.New("no snapshots returned by the API")
+	}
+	resources := make([]ImageResource, 0, len(*snapshots.Items))
+	for _, snapshot := range *snapshots.Items {
+		resources = append(resources, imageResourceFromSnapshot(snapshot))
+	}
+	if len(q.Labels) > 0 {
+		if err := attachLabels(ctx, client, resources); err != nil {
+			return nil, err
+		}
+	}
+	q.Visibility = imageVisibilityAny
+	return q.find(resources, "snapshot")
+}
+
+// attachLabels fills in the labels of resources with a single call to the
+// Labels API.
+func attachLabels(ctx context.Context, client *ionoscloud.APIClient, resources []ImageResource) error {
+	labels, _, err := client.LabelsApi.LabelsGet(ctx).Execute()
+	if err != nil {
+		return fmt.Errorf("error getting labels: %w", err)
+	}
+	if labels.Items == nil {
+		return nil
+	}
+	byId := make(map[string]map[string]string)
+	for _, l := range *labels.Items {
+		if l.Properties == nil {
+			continue
+		}
+		id := stringValue(l.Properties.ResourceId)
+		if byId[id] == nil {
+			byId[id] = make(map[string]string)
+		}
+		byId[id][stringValue(l.Properties.Key)] = stringValue(l.Properties.Value)
+	}
+	for i := range resources {
+		resources[i].Labels = byId[resources[i].Id]
+	}
+	return nil
+}
+
+// sortImageResourcesByCreation sorts resources newest first. Resources with
+// the same creation date are ordered by name so the result does not depend
+// on the order the API returned them in.
+func sortImageResourcesByCreation(resources []ImageResource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		if !resources[i].Created.Equal(resources[j].Created) {
+			return resources[i].Created.After(resources[j].Created)
+		}
+		return resources[i].Name > resources[j].Name
+	})
+}
+
+// imageResourceNames formats resources as a sorted "name (id)" list for
+// error messages.
+func imageResourceNames(resources []ImageResource) string {
+	if len(resources) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(resources))
+	for _, r := range resources {
+		names = append(names, fmt.Sprintf("%s (%s)", r.Name, r.Id))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,255 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+func testImage(id, name, location string, created time.Time) ionoscloud.Image {
+	return ionoscloud.Image{
+		Id: ionoscloud.PtrString(id),
+		Metadata: &ionoscloud.DatacenterElementMetadata{
+			CreatedDate: &ionoscloud.IonosTime{Time: created},
+		},
+		Properties: &ionoscloud.ImageProperties{
+			Name:        ionoscloud.PtrString(name),
+			Location:    ionoscloud.PtrString(location),
+			ImageType:   ionoscloud.PtrString("HDD"),
+			LicenceType: ionoscloud.PtrString("LINUX"),
+			Public:      ionoscloud.PtrBool(true),
+		},
+	}
+}
+
+func testImages() []ImageResource {
+	images := []ionoscloud.Image{
+		testImage("1", "ubuntu-22.04-server-cloudimg-amd64-20230901", "de/fra", time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)),
+		testImage("2", "ubuntu-22.04-server-cloudimg-amd64-20231001", "de/fra", time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)),
+		testImage("3", "ubuntu-20.04-server-cloudimg-amd64-20231001", "de/fra", time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)),
+		testImage("4", "ubuntu-22.04-server-cloudimg-amd64-20231101", "us/las", time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)),
+		testImage("5", "debian-12-genericcloud-amd64-20231001", "de/fra", time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	private := testImage("6", "debian-12-hardened", "de/fra", time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC))
+	private.Properties.Public = ionoscloud.PtrBool(false)
+	images = append(images, private)
+
+	resources := make([]ImageResource, 0, len(images))
+	for _, img := range images {
+		resources = append(resources, imageResourceFromImage(img))
+	}
+	return resources
+}
+
+func TestIsUUID(t *testing.T) {
+	if !isUUID("b8a6e8a2-2c5c-4b0c-9a1a-5b8e3f1f3c2d") {
+		t.Fatal("should be a uuid")
+	}
+	if isUUID("Ubuntu-22.04") {
+		t.Fatal("should not be a uuid")
+	}
+}
+
+func TestFindImage(t *testing.T) {
+	cases := []struct {
+		name       string
+		image      string
+		match      string
+		mostRecent bool
+		expected   string
+		err        string
+	}{
+		{"exact", "debian-12-genericcloud-amd64-20231001", imageMatchExact, false, "5", ""},
+		{"exact is case insensitive", "DEBIAN-12-genericcloud-amd64-20231001", imageMatchExact, false, "5", ""},
+		{"exact no match", "debian-12", imageMatchExact, false, "", "no image in de/fra matches"},
+		{"prefix", "ubuntu-20", imageMatchPrefix, false, "3", ""},
+		{"contains ambiguous", "22.04", imageMatchContains, false, "", "2 images in de/fra match"},
+		{"private images are excluded", "debian-12-hardened", imageMatchExact, false, "", "no image in de/fra matches"},
+		{"contains most recent", "22.04", imageMatchContains, true, "2", ""},
+		{"regex most recent", `^ubuntu-\d+\.04-`, imageMatchRegex, true, "2", ""},
+		{"regex", `^debian-12-`, imageMatchRegex, false, "5", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := imageQuery{
+				Name:       tc.image,
+				Match:      tc.match,
+				Location:   "de/fra",
+				Visibility: imageVisibilityPublic,
+				MostRecent: tc.mostRecent,
+			}
+			img, err := q.find(testImages(), "image")
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if img.Id != tc.expected {
+				t.Fatalf("expected image %s, got %s", tc.expected, img.Id)
+			}
+		})
+	}
+}
+
+func TestFindImage_ErrorListsCandidates(t *testing.T) {
+	q := imageQuery{Name: "ubuntu-22.04", Match: imageMatchPrefix, Location: "de/fra", Visibility: imageVisibilityPublic}
+	_, err := q.find(testImages(), "image")
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	for _, name := range []string{"20230901 (1)", "20231001 (2)"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Fatalf("error should list %s: %s", name, err)
+		}
+	}
+}
+
+func TestFindImage_Visibility(t *testing.T) {
+	q := imageQuery{Name: "debian-12", Match: imageMatchPrefix, Location: "de/fra", Visibility: imageVisibilityPrivate}
+	img, err := q.find(testImages(), "image")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if img.Id != "6" {
+		t.Fatalf("expected the private image, got %s", img.Id)
+	}
+
+	q.Visibility = imageVisibilityAny
+	if _, err := q.find(testImages(), "image"); err == nil {
+		t.Fatal("public and private image should both match")
+	}
+}
+
+func TestFindImage_Filter(t *testing.T) {
+	images := testImages()
+	images[0].CloudInit = "V1"
+	images[1].CloudInit = "NONE"
+	images[4].LicenceType = "OTHER"
+
+	f := &ImageFilter{Name: `^ubuntu-22\.04-`, CloudInit: "v1", Visibility: imageVisibilityPublic}
+	img, err := f.query("de/fra").find(images, "image")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if img.Id != "1" {
+		t.Fatalf("expected the cloud-init image, got %s", img.Id)
+	}
+
+	f = &ImageFilter{LicenceType: "linux", Visibility: imageVisibilityAny, MostRecent: true}
+	img, err = f.query("de/fra").find(images, "image")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if img.Id != "2" {
+		t.Fatalf("expected the most recent linux image, got %s", img.Id)
+	}
+
+	f = &ImageFilter{Location: "us/las", Labels: map[string]string{"team": "platform"}}
+	if _, err := f.query("de/fra").find(images, "image"); err == nil {
+		t.Fatal("images without the label should not match")
+	}
+	images[3].Labels = map[string]string{"team": "platform"}
+	img, err = f.query("de/fra").find(images, "image")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if img.Id != "4" {
+		t.Fatalf("expected the labeled image, got %s", img.Id)
+	}
+}
+
+func TestFindSnapshot(t *testing.T) {
+	snapshot := func(id, name string, created time.Time) ImageResource {
+		return imageResourceFromSnapshot(ionoscloud.Snapshot{
+			Id: ionoscloud.PtrString(id),
+			Metadata: &ionoscloud.DatacenterElementMetadata{
+				CreatedDate: &ionoscloud.IonosTime{Time: created},
+			},
+			Properties: &ionoscloud.SnapshotProperties{
+				Name:     ionoscloud.PtrString(name),
+				Location: ionoscloud.PtrString("de/fra"),
+			},
+		})
+	}
+	snapshots := []ImageResource{
+		snapshot("1", "base-hardened-1", time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)),
+		snapshot("2", "base-hardened-2", time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)),
+		snapshot("3", "app-1", time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	q := imageQuery{Name: "base-hardened", Match: imageMatchPrefix, Location: "de/fra", Visibility: imageVisibilityAny, MostRecent: true}
+	found, err := q.find(snapshots, "snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if found.Id != "2" {
+		t.Fatalf("expected the most recent snapshot, got %s", found.Id)
+	}
+}
+
+func TestSnapshotFilter(t *testing.T) {
+	snapshot := func(id, name string, created time.Time) ImageResource {
+		return ImageResource{Id: id, Name: name, Location: "de/fra", ImageType: "HDD", Created: created}
+	}
+	snapshots := []ImageResource{
+		snapshot("1", "base-hardened-1", time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)),
+		snapshot("2", "base-hardened-2", time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)),
+		snapshot("3", "base-hardened", time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	f := &SnapshotFilter{Name: "base-hardened", Location: "de/fra"}
+	found, err := f.query().find(snapshots, "snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if found.Id != "3" {
+		t.Fatalf("name should match exactly, got %s", found.Id)
+	}
+
+	f = &SnapshotFilter{NameRegex: "^base-hardened", Location: "de/fra"}
+	if _, err := f.query().find(snapshots, "snapshot"); err == nil || !strings.Contains(err.Error(), "3 snapshots") {
+		t.Fatalf("ambiguous match should fail, got %v", err)
+	}
+
+	f.MostRecent = true
+	found, err = f.query().find(snapshots, "snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if found.Id != "2" {
+		t.Fatalf("expected the most recent snapshot, got %s", found.Id)
+	}
+
+	f = &SnapshotFilter{Name: "a", NameRegex: "b"}
+	if errs := f.Prepare(); len(errs) != 2 {
+		t.Fatalf("expected name and location errors, got %v", errs)
+	}
+}
+
+func TestCheckImageAlias(t *testing.T) {
+	location := ionoscloud.Location{
+		Properties: &ionoscloud.LocationProperties{
+			ImageAliases: &[]string{"ubuntu:latest", "debian:latest"},
+		},
+	}
+	if err := checkImageAlias(location, "de/fra", "ubuntu:latest"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err := checkImageAlias(location, "de/fra", "centos:latest")
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	if !strings.Contains(err.Error(), "debian:latest, ubuntu:latest") {
+		t.Fatalf("error should list the available aliases: %s", err)
+	}
+}
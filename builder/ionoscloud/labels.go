@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"fmt"
+	"sort"
+
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+const (
+	labelBuildName = "packer-build-name"
+	labelRunUUID   = "packer-run-uuid"
+)
+
+// addLabels adds labels to a resource in a stable order. post creates a
+// single label on the resource through the Labels API.
+func addLabels(labels map[string]string, post func(ionoscloud.LabelResource) error) error {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		label := ionoscloud.LabelResource{
+			Properties: &ionoscloud.LabelResourceProperties{
+				Key:   ionoscloud.PtrString(k),
+				Value: ionoscloud.PtrString(labels[k]),
+			},
+		}
+		if err := post(label); err != nil {
+			return fmt.Errorf("error adding label %s: %w", k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"errors"
+	"testing"
+
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+func TestAddLabels(t *testing.T) {
+	var keys []string
+	err := addLabels(map[string]string{"team": "infra", "env": "ci"}, func(l ionoscloud.LabelResource) error {
+		keys = append(keys, *l.Properties.Key+"="+*l.Properties.Value)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(keys) != 2 || keys[0] != "env=ci" || keys[1] != "team=infra" {
+		t.Fatalf("labels should be added in order: %v", keys)
+	}
+
+	err = addLabels(map[string]string{"env": "ci"}, func(ionoscloud.LabelResource) error {
+		return errors.New("conflict")
+	})
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newRetryTransport(nil, 3, time.Millisecond, time.Millisecond)}
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("{}"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("bad status code: %d", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetryTransport_GivesUp(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newRetryTransport(nil, 2, time.Millisecond, time.Millisecond)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("bad status code: %d", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	cases := []struct {
+		method string
+		status int
+		retry  bool
+	}{
+		{http.MethodGet, http.StatusTooManyRequests, true},
+		{http.MethodPost, http.StatusTooManyRequests, true},
+		{http.MethodPost, http.StatusServiceUnavailable, true},
+		{http.MethodGet, http.StatusInternalServerError, true},
+		{http.MethodPost, http.StatusInternalServerError, false},
+		{http.MethodDelete, http.StatusGatewayTimeout, true},
+		{http.MethodGet, http.StatusNotFound, false},
+		{http.MethodGet, http.StatusOK, false},
+	}
+	for _, tc := range cases {
+		resp := &http.Response{StatusCode: tc.status}
+		if got := shouldRetry(tc.method, resp, nil); got != tc.retry {
+			t.Errorf("%s %d: expected %v, got %v", tc.method, tc.status, tc.retry, got)
+		}
+	}
+}
+
+func TestRetryTransport_Backoff(t *testing.T) {
+	rt := newRetryTransport(nil, 5, time.Second, 5*time.Second)
+
+	if d := rt.backoff(0, nil); d != time.Second {
+		t.Fatalf("bad first backoff: %s", d)
+	}
+	if d := rt.backoff(2, nil); d != 4*time.Second {
+		t.Fatalf("bad third backoff: %s", d)
+	}
+	if d := rt.backoff(10, nil); d != 5*time.Second {
+		t.Fatalf("backoff should be capped: %s", d)
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"42"}}}
+	if d := rt.backoff(0, resp); d != 42*time.Second {
+		t.Fatalf("Retry-After should be honored: %s", d)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// quotaBackoffMin and quotaBackoffMax bound the wait between two quota checks
+// while waiting for capacity to free up.
+const (
+	quotaBackoffMin = 30 * time.Second
+	quotaBackoffMax = 5 * time.Minute
+)
+
+// stepCheckQuota compares the resources the build needs with the free
+// capacity of the contract. It refuses to start the build, or waits until
+// enough capacity has been released, so builds don't fail half way through.
+type stepCheckQuota struct {
+	client *ionoscloud.APIClient
+}
+
+func newStepCheckQuota(client *ionoscloud.APIClient) *stepCheckQuota {
+	return &stepCheckQuota{
+		client: client,
+	}
+}
+
+func (s *stepCheckQuota) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !c.CheckQuota {
+		return multistep.ActionContinue
+	}
+
+	ui.Say("Checking contract resource limits...")
+	req := newQuotaRequest(c)
+	deadline := time.Now().Add(c.QuotaWaitTimeout)
+	backoff := quotaBackoffMin
+	for {
+		limits, err := s.getResourceLimits(ctx)
+		if err != nil {
+			return s.halt(state, ui, err)
+		}
+
+		fixed, exceeded := checkQuota(limits, req)
+		// limits per server and volume don't change by waiting
+		if len(fixed) > 0 {
+			return s.halt(state, ui, &packersdk.MultiError{Errors: append(fixed, exceeded...)})
+		}
+		if len(exceeded) == 0 {
+			return multistep.ActionContinue
+		}
+		if c.QuotaWaitTimeout == 0 || time.Now().Add(backoff).After(deadline) {
+			return s.halt(state, ui, &packersdk.MultiError{Errors: exceeded})
+		}
+
+		ui.Say(fmt.Sprintf("Not enough free capacity in the contract, checking again in %s: %s",
+			backoff, joinErrors(exceeded)))
+		select {
+		case <-ctx.Done():
+			return s.halt(state, ui, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > quotaBackoffMax {
+			backoff = quotaBackoffMax
+		}
+	}
+}
+
+func (s *stepCheckQuota) Cleanup(_ multistep.StateBag) {
+}
+
+func (s *stepCheckQuota) halt(state multistep.StateBag, ui packersdk.Ui, err error) multistep.StepAction {
+	err = fmt.Errorf("contract quota check failed: %w", err)
+	state.Put("error", err)
+	ui.Error(err.Error())
+	return multistep.ActionHalt
+}
+
+func (s *stepCheckQuota) getResourceLimits(ctx context.Context) (ionoscloud.ResourceLimits, error) {
+	contracts, _, err := s.client.ContractResourcesApi.ContractsGet(ctx).Depth(1).Execute()
+	if err != nil {
+		return ionoscloud.ResourceLimits{}, fmt.Errorf("error getting the contract: %w", err)
+	}
+	if contracts.Items != nil {
+		for _, contract := range *contracts.Items {
+			if contract.Properties != nil && contract.Properties.ResourceLimits != nil {
+				return *contract.Properties.ResourceLimits, nil
+			}
+		}
+	}
+	return ionoscloud.ResourceLimits{}, errors.New("no resource limits returned by the API")
+}
+
+// quotaRequest is what a build provisions on the contract. Sizes are in MB,
+// as reported by the Contracts API.
+type quotaRequest struct {
+	Cores int32
+	Ram   int32
+	Ips   int32
+	// Hdd and Ssd are the sizes of the volumes of each kind.
+	Hdd []int64
+	Ssd []int64
+}
+
+// newQuotaRequest returns the resources the build server of c needs. The
+// cores, RAM and DAS volume of a CUBE server are given by its template and
+// are not known up front. The datacenter the build may create is not
+// counted, the Contracts API has no limit for datacenters.
+func newQuotaRequest(c *Config) quotaRequest {
+	req := quotaRequest{Cores: c.Cores, Ram: c.Ram}
+	if c.ReserveIp {
+		req.Ips = 1
+	}
+	add := func(diskType string, size float32) {
+		mb := int64(size * 1024)
+		switch {
+		case strings.HasPrefix(diskType, "SSD"):
+			req.Ssd = append(req.Ssd, mb)
+		case diskType == "HDD":
+			req.Hdd = append(req.Hdd, mb)
+		}
+	}
+	add(c.DiskType, c.DiskSize)
+	for _, v := range c.Volumes {
+		add(v.Type, v.Size)
+	}
+	return req
+}
+
+// checkQuota compares req with limits. fixed are the limits per server or
+// volume that req can never meet, exceeded are the contract totals that the
+// build would exceed with what is provisioned right now.
+func checkQuota(limits ionoscloud.ResourceLimits, req quotaRequest) (fixed []error, exceeded []error) {
+	perResource := func(name string, requested, limit int64) {
+		if limit > 0 && requested > limit {
+			fixed = append(fixed, fmt.Errorf("%s %d exceeds the limit of %d", name, requested, limit))
+		}
+	}
+	perContract := func(name string, requested, provisioned, limit int64) {
+		if requested > 0 && limit > 0 && provisioned+requested > limit {
+			exceeded = append(exceeded, fmt.Errorf("%s: %d requested, %d of %d in use",
+				name, requested, provisioned, limit))
+		}
+	}
+
+	perResource("cores per server", int64(req.Cores), int64(int32Value(limits.CoresPerServer)))
+	perResource("RAM per server (MB)", int64(req.Ram), int64(int32Value(limits.RamPerServer)))
+	var hdd, ssd int64
+	for _, size := range req.Hdd {
+		perResource("HDD volume size (MB)", size, int64Value(limits.HddLimitPerVolume))
+		hdd += size
+	}
+	for _, size := range req.Ssd {
+		perResource("SSD volume size (MB)", size, int64Value(limits.SsdLimitPerVolume))
+		ssd += size
+	}
+
+	perContract("cores", int64(req.Cores), int64(int32Value(limits.CoresProvisioned)), int64(int32Value(limits.CoresPerContract)))
+	perContract("RAM (MB)", int64(req.Ram), int64(int32Value(limits.RamProvisioned)), int64(int32Value(limits.RamPerContract)))
+	perContract("HDD (MB)", hdd, int64Value(limits.HddVolumeProvisioned), int64Value(limits.HddLimitPerContract))
+	perContract("SSD (MB)", ssd, int64Value(limits.SsdVolumeProvisioned), int64Value(limits.SsdLimitPerContract))
+	perContract("reserved IPs", int64(req.Ips), int64(int32Value(limits.ReservedIpsInUse)), int64(int32Value(limits.ReservedIpsOnContract)))
+	return fixed, exceeded
+}
+
+func joinErrors(errs []error) string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func int32Value(v *int32) int32 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func int64Value(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"testing"
+
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+func TestNewQuotaRequest(t *testing.T) {
+	c := &Config{
+		Cores:     4,
+		Ram:       2048,
+		DiskType:  "SSD",
+		DiskSize:  10,
+		ReserveIp: true,
+		Volumes:   []Volume{{Type: "HDD", Size: 100}, {Type: "SSD Premium", Size: 1}},
+	}
+	req := newQuotaRequest(c)
+	if req.Cores != 4 || req.Ram != 2048 || req.Ips != 1 {
+		t.Fatalf("bad quota request: %#v", req)
+	}
+	if len(req.Ssd) != 2 || req.Ssd[0] != 10240 || len(req.Hdd) != 1 || req.Hdd[0] != 102400 {
+		t.Fatalf("bad volume sizes: %#v", req)
+	}
+
+	cube := newQuotaRequest(&Config{DiskType: "DAS"})
+	if len(cube.Hdd) != 0 || len(cube.Ssd) != 0 {
+		t.Fatalf("a DAS volume should not count against the HDD or SSD limits: %#v", cube)
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	limits := ionoscloud.ResourceLimits{
+		CoresPerServer:        ionoscloud.PtrInt32(16),
+		CoresPerContract:      ionoscloud.PtrInt32(20),
+		CoresProvisioned:      ionoscloud.PtrInt32(14),
+		RamPerServer:          ionoscloud.PtrInt32(65536),
+		RamPerContract:        ionoscloud.PtrInt32(102400),
+		RamProvisioned:        ionoscloud.PtrInt32(8192),
+		HddLimitPerVolume:     ionoscloud.PtrInt64(4194304),
+		HddLimitPerContract:   ionoscloud.PtrInt64(4194304),
+		HddVolumeProvisioned:  ionoscloud.PtrInt64(0),
+		SsdLimitPerVolume:     ionoscloud.PtrInt64(1048576),
+		SsdLimitPerContract:   ionoscloud.PtrInt64(1048576),
+		SsdVolumeProvisioned:  ionoscloud.PtrInt64(1048576),
+		ReservedIpsOnContract: ionoscloud.PtrInt32(5),
+		ReservedIpsInUse:      ionoscloud.PtrInt32(1),
+	}
+
+	fixed, exceeded := checkQuota(limits, quotaRequest{Cores: 4, Ram: 2048, Ips: 1, Hdd: []int64{51200}})
+	if len(fixed) != 0 || len(exceeded) != 0 {
+		t.Fatalf("should fit into the contract: %v %v", fixed, exceeded)
+	}
+
+	fixed, exceeded = checkQuota(limits, quotaRequest{Cores: 8, Ram: 2048, Ssd: []int64{10240}})
+	if len(fixed) != 0 || len(exceeded) != 2 {
+		t.Fatalf("cores and SSD should exceed the contract: %v %v", fixed, exceeded)
+	}
+
+	fixed, _ = checkQuota(limits, quotaRequest{Cores: 32, Ram: 131072})
+	if len(fixed) != 2 {
+		t.Fatalf("cores and RAM should exceed the per server limits: %v", fixed)
+	}
+}
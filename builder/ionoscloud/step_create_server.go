@@ -8,21 +8,25 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
 	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
 )
 
 type stepCreateServer struct {
-	client *ionoscloud.APIClient
+	client        *ionoscloud.APIClient
+	generatedData *packerbuilderdata.GeneratedData
 }
 
-func newStepCreateServer(client *ionoscloud.APIClient) *stepCreateServer {
+func newStepCreateServer(client *ionoscloud.APIClient, generatedData *packerbuilderdata.GeneratedData) *stepCreateServer {
 	return &stepCreateServer{
-		client: client,
+		client:        client,
+		generatedData: generatedData,
 	}
 }
 
@@ -30,95 +34,188 @@ func (s *stepCreateServer) Run(ctx context.Context, state multistep.StateBag) mu
 	ui := state.Get("ui").(packersdk.Ui)
 	c := state.Get("config").(*Config)
 
-	ui.Say("Creating Virtual Data Center...")
-	img, err := s.getImageId(c.Image, c)
-	if err != nil {
-		ui.Error(fmt.Sprintf("Error occurred while getting image %s", err.Error()))
-		return multistep.ActionHalt
-	}
+	src := state.Get("source_image").(*sourceImage)
 
 	props := &ionoscloud.VolumeProperties{
-		Type:  ionoscloud.PtrString(c.DiskType),
-		Size:  ionoscloud.PtrFloat32(c.DiskSize),
-		Name:  ionoscloud.PtrString(c.SnapshotName),
-		Image: ionoscloud.PtrString(img),
-	}
-	nic := ionoscloud.Nic{
-		Properties: &ionoscloud.NicProperties{
-			Name: ionoscloud.PtrString(c.SnapshotName),
-			Dhcp: ionoscloud.PtrBool(true),
-		},
+		Type: ionoscloud.PtrString(c.DiskType),
+		Name: ionoscloud.PtrString(c.SnapshotName),
+	}
+	if c.DiskSize != 0 {
+		// the size of the DAS volume of a CUBE server is given by the template
+		props.Size = ionoscloud.PtrFloat32(c.DiskSize)
+	}
+	if src.Alias != "" {
+		props.ImageAlias = ionoscloud.PtrString(src.Alias)
+	} else {
+		props.Image = ionoscloud.PtrString(src.Id)
+	}
+	var publicIp string
+	var err error
+	if commNic := c.communicatorNic(); *commNic.FirewallActive && c.FirewallSourcePublicIp {
+		publicIp, err = detectPublicIp(ctx)
+		if err != nil {
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		ui.Say(fmt.Sprintf("Allowing %s from %s", c.Comm.Type, publicIp))
+	}
+	if !src.Public {
+		// the API only injects passwords and SSH keys into public images
+		ui.Say(fmt.Sprintf("Source %s %s is private, the communicator credentials must already be set up in it", src.Type, src.Name))
+	} else {
+		if c.Comm.SSHPassword != "" {
+			props.ImagePassword = ionoscloud.PtrString(c.Comm.SSHPassword)
+		}
+		if c.Comm.SSHPublicKey != nil {
+			props.SshKeys = &[]string{string(c.Comm.SSHPublicKey)}
+		}
 	}
-	if c.Comm.SSHPassword != "" {
-		props.ImagePassword = ionoscloud.PtrString(c.Comm.SSHPassword)
+	volumes := []ionoscloud.Volume{{Properties: props}}
+	for _, v := range c.Volumes {
+		volumes = append(volumes, newDataVolume(v))
 	}
-	if c.Comm.SSHPublicKey != nil {
-		props.SshKeys = &[]string{string(c.Comm.SSHPublicKey)}
+	serverProps, err := s.serverProperties(ctx, c)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error occurred while getting the CUBE template %s", err.Error()))
+		return multistep.ActionHalt
 	}
 	serverReq := ionoscloud.Server{
-		Properties: &ionoscloud.ServerProperties{
-			Name:  ionoscloud.PtrString(c.SnapshotName),
-			Ram:   ionoscloud.PtrInt32(c.Ram),
-			Cores: ionoscloud.PtrInt32(c.Cores),
-		},
+		Properties: serverProps,
 		Entities: &ionoscloud.ServerEntities{
 			Volumes: &ionoscloud.AttachedVolumes{
-				Items: &[]ionoscloud.Volume{
-					{
-						Properties: props,
-					},
-				},
-			},
-			Nics: &ionoscloud.Nics{
-				Items: &[]ionoscloud.Nic{
-					nic,
-				},
+				Items: &volumes,
 			},
 		},
 	}
 
-	// create datacenter
-	dc, err := s.createDcAndWaitUntilDone(ctx, c.SnapshotName, c.Region)
-	if err != nil {
-		ui.Error(fmt.Sprintf("Error occurred while creating a datacenter %s", err.Error()))
-		return multistep.ActionHalt
+	var dcId string
+	if c.DatacenterId != "" || c.DatacenterName != "" {
+		ui.Say("Using existing Virtual Data Center...")
+		dcId, err = s.findDatacenter(ctx, c)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while getting the datacenter %s", err.Error()))
+			return multistep.ActionHalt
+		}
+	} else {
+		ui.Say("Creating Virtual Data Center...")
+		// create datacenter
+		dc, err := s.createDcAndWaitUntilDone(ctx, c.SnapshotName, c.Region)
+		if dc != nil {
+			dcId = *dc.Id
+			// only a datacenter created by the build is deleted on cleanup
+			state.Put("datacenter_created", true)
+			state.Put("datacenter_id", dcId)
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while creating a datacenter %s", err.Error()))
+			return multistep.ActionHalt
+		}
+		err = addLabels(c.RunLabels, func(l ionoscloud.LabelResource) error {
+			_, _, err := s.client.LabelsApi.DatacentersLabelsPost(ctx, dcId).Label(l).Execute()
+			return err
+		})
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while labelling the datacenter %s", err.Error()))
+			return multistep.ActionHalt
+		}
 	}
-	dcId := *dc.Id
 	state.Put("datacenter_id", dcId)
+	s.generatedData.Put("DatacenterID", dcId)
+
+	// the build LAN is only needed by NICs that do not name a LAN of their own
+	var lanId string
+	switch {
+	case !c.usesBuildLan():
+	case c.LanId != "" || c.LanName != "":
+		ui.Say("Using existing LAN...")
+		lanId, err = s.findLan(ctx, dcId, c.LanId, c.LanName)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while getting the LAN %s", err.Error()))
+			return multistep.ActionHalt
+		}
+	default:
+		lanPost := ionoscloud.LanPost{
+			Properties: &ionoscloud.LanPropertiesPost{
+				Public: ionoscloud.PtrBool(true),
+				Name:   ionoscloud.PtrString(c.SnapshotName),
+			},
+		}
 
-	lanPost := ionoscloud.LanPost{
-		Properties: &ionoscloud.LanPropertiesPost{
-			Public: ionoscloud.PtrBool(true),
-			Name:   ionoscloud.PtrString(c.SnapshotName),
-		},
+		ui.Say("Creating LAN...")
+		// create lan
+		lan, err := s.createLanAndWaitUntilDone(ctx, dcId, lanPost)
+		if lan != nil {
+			lanId = *lan.Id
+			state.Put("lan_id", lanId)
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while creating a server %s", err.Error()))
+			return multistep.ActionHalt
+		}
 	}
 
-	ui.Say("Creating LAN...")
-	// create lan
-	lan, err := s.createLanAndWaitUntilDone(ctx, dcId, lanPost)
-	if err != nil {
-		ui.Error(fmt.Sprintf("Error occurred while creating a server %s", err.Error()))
-		return multistep.ActionHalt
+	var reservedIp string
+	if ip, ok := state.GetOk("reserved_ip"); ok {
+		reservedIp = ip.(string)
 	}
-
-	// string to int
-	lanId, err := strconv.Atoi(*lan.Id)
-	if err != nil {
-		ui.Error(fmt.Sprintf("Error occurred while creating a server %s", err.Error()))
-		return multistep.ActionHalt
+	nics := make([]ionoscloud.Nic, 0, len(c.Nics))
+	for _, n := range c.Nics {
+		nicLanId := lanId
+		if n.LanId != "" || n.LanName != "" {
+			nicLanId, err = s.findLan(ctx, dcId, n.LanId, n.LanName)
+			if err != nil {
+				ui.Error(fmt.Sprintf("Error occurred while getting the LAN of NIC %s %s", n.Name, err.Error()))
+				return multistep.ActionHalt
+			}
+		}
+		// string to int
+		lan, err := strconv.Atoi(nicLanId)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while creating a server %s", err.Error()))
+			return multistep.ActionHalt
+		}
+		nics = append(nics, newNic(c, n, int32(lan), publicIp, reservedIp))
 	}
-	nic.Properties.Lan = ionoscloud.PtrInt32(int32(lanId))
+	// the firewall rules are part of the server request, the server is
+	// never reachable without them
+	serverReq.Entities.Nics = &ionoscloud.Nics{Items: &nics}
 
 	ui.Say("Creating Server...")
 	// create server
 	server, err := s.createServerAndWaitUntilDone(ctx, dcId, serverReq)
+	if server != nil {
+		state.Put("server_id", *server.Id)
+	}
 	if err != nil {
 		ui.Error(fmt.Sprintf("Error occurred while creating a server %s", err.Error()))
 		return multistep.ActionHalt
 	}
 
-	volumes := *server.Entities.Volumes.Items
-	state.Put("volume_id", *volumes[0].Id)
+	volumeIds := volumeIdsByName(server)
+	if volumeIds[c.SnapshotName] == "" {
+		ui.Error(fmt.Sprintf("Error occurred while creating a server: no volume %s in the response", c.SnapshotName))
+		return multistep.ActionHalt
+	}
+	state.Put("volume_id", volumeIds[c.SnapshotName])
+	s.generatedData.Put("VolumeID", volumeIds[c.SnapshotName])
+	dataVolumes := make([]dataVolume, 0, len(c.Volumes))
+	for _, v := range c.Volumes {
+		if volumeIds[v.Name] == "" {
+			ui.Error(fmt.Sprintf("Error occurred while creating a server: no volume %s in the response", v.Name))
+			return multistep.ActionHalt
+		}
+		dataVolumes = append(dataVolumes, dataVolume{
+			Id:           volumeIds[v.Name],
+			Name:         v.Name,
+			SnapshotName: v.SnapshotName,
+		})
+	}
+	state.Put("data_volumes", dataVolumes)
+
+	if err := s.labelServer(ctx, c, dcId, *server.Id, volumeIds); err != nil {
+		ui.Error(fmt.Sprintf("Error occurred while labelling the server %s", err.Error()))
+		return multistep.ActionHalt
+	}
 
 	server, err = s.findServerById(ctx, dcId, *server.Id)
 	if err != nil {
@@ -129,10 +226,15 @@ func (s *stepCreateServer) Run(ctx context.Context, state multistep.StateBag) mu
 	// instance_id is the generic term used so that users can have access to the
 	// instance id inside of the provisioners, used in step_provision.
 	state.Put("instance_id", *server.Id)
+	s.generatedData.Put("ServerID", *server.Id)
 
-	nics := *server.Entities.Nics.Items
-	ips := *nics[0].Properties.Ips
-	state.Put("server_ip", ips[0])
+	ip, err := serverIP(server, c.CommunicatorNic)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error occurred while getting the server IP %s", err.Error()))
+		return multistep.ActionHalt
+	}
+	state.Put("server_ip", ip)
+	s.generatedData.Put("ServerIP", ip)
 	ui.Say("Server Created...")
 
 	return multistep.ActionContinue
@@ -141,9 +243,13 @@ func (s *stepCreateServer) Run(ctx context.Context, state multistep.StateBag) mu
 func (s *stepCreateServer) Cleanup(state multistep.StateBag) {
 	ui := state.Get("ui").(packersdk.Ui)
 
-	ui.Say("Removing Virtual Data Center...")
+	dcId, ok := state.GetOk("datacenter_id")
+	if !ok {
+		return
+	}
 
-	if dcId, ok := state.GetOk("datacenter_id"); ok {
+	if _, created := state.GetOk("datacenter_created"); created {
+		ui.Say("Removing Virtual Data Center...")
 		deleted, err := s.deleteDatacenter(s.client, dcId.(string))
 		if err != nil {
 			ui.Error(fmt.Sprintf(
@@ -152,55 +258,333 @@ func (s *stepCreateServer) Cleanup(state multistep.StateBag) {
 		if deleted {
 			ui.Say("Virtual Data Center deleted...")
 		}
+		return
+	}
+
+	// the datacenter is not ours, only remove what the build created in it
+	ctx := context.Background()
+	if serverId, ok := state.GetOk("server_id"); ok {
+		ui.Say("Removing Server...")
+		if err := s.deleteServer(ctx, dcId.(string), serverId.(string)); err != nil {
+			ui.Error(fmt.Sprintf(
+				"Error deleting server. Please destroy it manually: %s", err))
+		}
+	}
+	if lanId, ok := state.GetOk("lan_id"); ok {
+		ui.Say("Removing LAN...")
+		if err := s.deleteLan(ctx, dcId.(string), lanId.(string)); err != nil {
+			ui.Error(fmt.Sprintf(
+				"Error deleting LAN. Please destroy it manually: %s", err))
+		}
 	}
 }
 
-func processRequestDatacenterDelete(apiClient *ionoscloud.APIClient, resourceID string) (*ionoscloud.APIResponse, error) {
-	apiRequest := apiClient.DataCentersApi.DatacentersFindById(context.Background(), resourceID)
-	_, apiResp, err := apiRequest.Execute()
+// deleteServer deletes a server together with its attached volumes and waits
+// for the deletion to finish.
+func (s *stepCreateServer) deleteServer(ctx context.Context, dcId, serverId string) error {
+	apiResponse, err := s.client.ServersApi.DatacentersServersDelete(ctx, dcId, serverId).DeleteVolumes(true).Execute()
 	if err != nil {
-		return apiResp, fmt.Errorf("error occurred when executing the api get resource operation: %w", err)
+		return fmt.Errorf("error deleting server (%w)", err)
+	}
+	if requestPath := getRequestPath(apiResponse); requestPath != "" {
+		return s.waitForRequestToBeDone(ctx, requestPath)
 	}
+	return nil
+}
 
-	return apiResp, nil
+// deleteLan deletes a LAN and waits for the deletion to finish.
+func (s *stepCreateServer) deleteLan(ctx context.Context, dcId, lanId string) error {
+	apiResponse, err := s.client.LANsApi.DatacentersLansDelete(ctx, dcId, lanId).Execute()
+	if err != nil {
+		return fmt.Errorf("error deleting LAN (%w)", err)
+	}
+	if requestPath := getRequestPath(apiResponse); requestPath != "" {
+		return s.waitForRequestToBeDone(ctx, requestPath)
+	}
+	return nil
 }
 
-func (s *stepCreateServer) deleteDatacenter(apiClient *ionoscloud.APIClient, datacenterID string) (bool, error) {
-	_, err := apiClient.DataCentersApi.DatacentersDelete(context.Background(), datacenterID).Execute()
+// findLan returns the ID of the existing LAN given by its ID or its name.
+func (s *stepCreateServer) findLan(ctx context.Context, dcId, id, name string) (string, error) {
+	if id != "" {
+		lan, _, err := s.client.LANsApi.DatacentersLansFindById(ctx, dcId, id).Execute()
+		if err != nil {
+			return "", fmt.Errorf("error getting LAN %s: %w", id, err)
+		}
+		return stringValue(lan.Id), nil
+	}
+
+	lans, _, err := s.client.LANsApi.DatacentersLansGet(ctx, dcId).Depth(1).Execute()
 	if err != nil {
-		return false, fmt.Errorf("error occurred when executing the api get resource operation: %w", err)
+		return "", fmt.Errorf("error getting LANs: %w", err)
 	}
-	return apiClient.WaitForDeletion(context.Background(), processRequestDatacenterDelete, datacenterID)
+	if lans.Items == nil {
+		return "", errors.New("no LANs returned by the API")
+	}
+	return selectLan(*lans.Items, name)
 }
 
-func (s *stepCreateServer) getImageId(imageName string, c *Config) (string, error) {
-	images, resp, err := s.client.ImagesApi.ImagesGet(context.Background()).Execute()
+// selectLan returns the ID of the single LAN called name.
+func selectLan(lans []ionoscloud.Lan, name string) (string, error) {
+	var ids []string
+	for _, lan := range lans {
+		if lan.Properties != nil && stringValue(lan.Properties.Name) == name {
+			ids = append(ids, stringValue(lan.Id))
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("no LAN named %q in the datacenter", name)
+	case 1:
+		return ids[0], nil
+	}
+	return "", fmt.Errorf("%d LANs are named %q: %s", len(ids), name, strings.Join(ids, ", "))
+}
+
+// labelServer adds the run labels to the build server and its volumes.
+func (s *stepCreateServer) labelServer(ctx context.Context, c *Config, dcId, serverId string, volumeIds map[string]string) error {
+	err := addLabels(c.RunLabels, func(l ionoscloud.LabelResource) error {
+		_, _, err := s.client.LabelsApi.DatacentersServersLabelsPost(ctx, dcId, serverId).Label(l).Execute()
+		return err
+	})
 	if err != nil {
-		return "", err
+		return err
+	}
+	for _, volumeId := range volumeIds {
+		err := addLabels(c.RunLabels, func(l ionoscloud.LabelResource) error {
+			_, _, err := s.client.LabelsApi.DatacentersVolumesLabelsPost(ctx, dcId, volumeId).Label(l).Execute()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("volume %s: %w", volumeId, err)
+		}
+	}
+	return nil
+}
+
+// serverProperties returns the properties of the build server. The template
+// of a CUBE server is looked up by name if no UUID is given.
+func (s *stepCreateServer) serverProperties(ctx context.Context, c *Config) (*ionoscloud.ServerProperties, error) {
+	props := &ionoscloud.ServerProperties{
+		Name: ionoscloud.PtrString(c.SnapshotName),
+		Type: ionoscloud.PtrString(c.ServerType),
+	}
+	if c.Cores != 0 {
+		props.Cores = ionoscloud.PtrInt32(c.Cores)
+	}
+	if c.Ram != 0 {
+		props.Ram = ionoscloud.PtrInt32(c.Ram)
+	}
+	if c.CpuFamily != "" {
+		props.CpuFamily = ionoscloud.PtrString(c.CpuFamily)
+	}
+	if c.AvailabilityZone != "" {
+		props.AvailabilityZone = ionoscloud.PtrString(c.AvailabilityZone)
+	}
+
+	switch {
+	case c.TemplateUuid != "":
+		props.TemplateUuid = ionoscloud.PtrString(c.TemplateUuid)
+	case c.TemplateName != "":
+		templates, _, err := s.client.TemplatesApi.TemplatesGet(ctx).Depth(1).Execute()
+		if err != nil {
+			return nil, fmt.Errorf("error getting templates: %w", err)
+		}
+		if templates.Items == nil {
+			return nil, errors.New("no templates returned by the API")
+		}
+		id, err := selectTemplate(*templates.Items, c.TemplateName)
+		if err != nil {
+			return nil, err
+		}
+		props.TemplateUuid = ionoscloud.PtrString(id)
+	}
+	return props, nil
+}
+
+// selectTemplate returns the ID of the template called name. Template names
+// are compared case-insensitively.
+func selectTemplate(templates []ionoscloud.Template, name string) (string, error) {
+	var names []string
+	for _, t := range templates {
+		if t.Properties == nil {
+			continue
+		}
+		if strings.EqualFold(stringValue(t.Properties.Name), name) {
+			return stringValue(t.Id), nil
+		}
+		names = append(names, stringValue(t.Properties.Name))
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("no CUBE template named %q, available templates: %s", name, strings.Join(names, ", "))
+}
+
+// dataVolume is an additional volume attached to the build server.
+type dataVolume struct {
+	Id   string
+	Name string
+	// SnapshotName is empty if no snapshot is taken of the volume.
+	SnapshotName string
+}
+
+// newDataVolume returns the request for the data volume v.
+func newDataVolume(v Volume) ionoscloud.Volume {
+	props := &ionoscloud.VolumeProperties{
+		Name: ionoscloud.PtrString(v.Name),
+		Size: ionoscloud.PtrFloat32(v.Size),
+		Type: ionoscloud.PtrString(v.Type),
+	}
+	if v.Bus != "" {
+		props.Bus = ionoscloud.PtrString(v.Bus)
+	}
+	if v.Image != "" {
+		props.Image = ionoscloud.PtrString(v.Image)
+	} else {
+		// the API requires a licence type for volumes without an image
+		props.LicenceType = ionoscloud.PtrString("OTHER")
+	}
+	return ionoscloud.Volume{Properties: props}
+}
+
+// volumeIdsByName maps the names of the volumes of server to their IDs.
+func volumeIdsByName(server *ionoscloud.Server) map[string]string {
+	ids := make(map[string]string)
+	if server.Entities == nil || server.Entities.Volumes == nil || server.Entities.Volumes.Items == nil {
+		return ids
+	}
+	for _, v := range *server.Entities.Volumes.Items {
+		if v.Properties != nil {
+			ids[stringValue(v.Properties.Name)] = stringValue(v.Id)
+		}
+	}
+	return ids
+}
+
+// newNic returns the request for the NIC n in lan. The communicator NIC gets
+// the reserved IP, if any, and a firewall rule per allowed source.
+func newNic(c *Config, n Nic, lan int32, publicIp, reservedIp string) ionoscloud.Nic {
+	communicator := n.Name == c.CommunicatorNic
+	props := &ionoscloud.NicProperties{
+		Name: ionoscloud.PtrString(n.Name),
+		Lan:  ionoscloud.PtrInt32(lan),
+		Dhcp: n.Dhcp,
+	}
+	ips := append([]string(nil), n.Ips...)
+	if communicator && reservedIp != "" {
+		ips = append([]string{reservedIp}, ips...)
 	}
-	if resp.StatusCode > 299 {
-		return "", errors.New("error occurred while getting images")
+	if len(ips) > 0 {
+		props.Ips = &ips
+	}
+	if n.Dhcpv6 != nil {
+		props.Dhcpv6 = n.Dhcpv6
+	}
+	if len(n.Ipv6Ips) > 0 {
+		ipv6Ips := append([]string(nil), n.Ipv6Ips...)
+		props.Ipv6Ips = &ipv6Ips
 	}
 
-	for i := 0; i < len(*images.Items); i++ {
-		imgName := ""
-		items := *images.Items
-		if *items[i].Properties.Name != "" {
-			imgName = *items[i].Properties.Name
+	nic := ionoscloud.Nic{Properties: props}
+	if *n.FirewallActive {
+		if !communicator {
+			publicIp = ""
+		}
+		rules := firewallRules(c, communicator, publicIp)
+		props.FirewallActive = ionoscloud.PtrBool(true)
+		nic.Entities = &ionoscloud.NicEntities{
+			Firewallrules: &ionoscloud.FirewallRules{Items: &rules},
 		}
-		diskType := c.DiskType
-		if c.DiskType == "SSD" {
-			diskType = "HDD"
+	}
+	return nic
+}
+
+// serverIP returns the first IP of the server NIC called name, the one the
+// communicator connects through. In a private LAN that is the private IP.
+func serverIP(server *ionoscloud.Server, name string) (string, error) {
+	if server.Entities == nil || server.Entities.Nics == nil || server.Entities.Nics.Items == nil {
+		return "", errors.New("server has no NICs")
+	}
+	for _, nic := range *server.Entities.Nics.Items {
+		props := nic.Properties
+		if props == nil || stringValue(props.Name) != name || props.Ips == nil {
+			continue
+		}
+		if ips := *props.Ips; len(ips) > 0 {
+			return ips[0], nil
+		}
+	}
+	return "", fmt.Errorf("server has no NIC %q with an IP", name)
+}
+
+// findDatacenter returns the ID of the existing datacenter given by
+// datacenter_id or datacenter_name. The datacenter must be in the build
+// location.
+func (s *stepCreateServer) findDatacenter(ctx context.Context, c *Config) (string, error) {
+	if c.DatacenterId != "" {
+		dc, _, err := s.client.DataCentersApi.DatacentersFindById(ctx, c.DatacenterId).Execute()
+		if err != nil {
+			return "", fmt.Errorf("error getting datacenter %s: %w", c.DatacenterId, err)
+		}
+		return selectDatacenter([]ionoscloud.Datacenter{dc}, "", c.Region)
+	}
+
+	dcs, _, err := s.client.DataCentersApi.DatacentersGet(ctx).Filter("name", c.DatacenterName).Execute()
+	if err != nil {
+		return "", fmt.Errorf("error getting datacenters: %w", err)
+	}
+	if dcs.Items == nil {
+		return "", errors.New("no datacenters returned by the API")
+	}
+	return selectDatacenter(*dcs.Items, c.DatacenterName, c.Region)
+}
+
+// selectDatacenter returns the ID of the single datacenter in location whose
+// name is name, or of any datacenter in location if name is empty.
+func selectDatacenter(dcs []ionoscloud.Datacenter, name, location string) (string, error) {
+	var ids, elsewhere []string
+	for _, dc := range dcs {
+		if dc.Properties == nil || name != "" && stringValue(dc.Properties.Name) != name {
+			continue
 		}
-		if imgName != "" && strings.Contains(strings.ToLower(imgName), strings.ToLower(imageName)) && *items[i].Properties.ImageType == diskType && *items[i].Properties.Location == c.Region && *items[i].Properties.Public {
-			return *items[i].Id, nil
+		if loc := stringValue(dc.Properties.Location); loc != location {
+			elsewhere = append(elsewhere, fmt.Sprintf("%s (%s)", stringValue(dc.Id), loc))
+			continue
 		}
+		ids = append(ids, stringValue(dc.Id))
 	}
-	return "", nil
+
+	switch {
+	case len(ids) == 1:
+		return ids[0], nil
+	case len(ids) > 1:
+		return "", fmt.Errorf("%d datacenters in %s are named %q: %s", len(ids), location, name, strings.Join(ids, ", "))
+	case len(elsewhere) > 0:
+		return "", fmt.Errorf("datacenter is not in %s: %s", location, strings.Join(elsewhere, ", "))
+	}
+	return "", fmt.Errorf("no datacenter named %q in %s", name, location)
+}
+
+func processRequestDatacenterDelete(apiClient *ionoscloud.APIClient, resourceID string) (*ionoscloud.APIResponse, error) {
+	apiRequest := apiClient.DataCentersApi.DatacentersFindById(context.Background(), resourceID)
+	_, apiResp, err := apiRequest.Execute()
+	if err != nil {
+		return apiResp, fmt.Errorf("error occurred when executing the api get resource operation: %w", err)
+	}
+
+	return apiResp, nil
+}
+
+func (s *stepCreateServer) deleteDatacenter(apiClient *ionoscloud.APIClient, datacenterID string) (bool, error) {
+	_, err := apiClient.DataCentersApi.DatacentersDelete(context.Background(), datacenterID).Execute()
+	if err != nil {
+		return false, fmt.Errorf("error occurred when executing the api get resource operation: %w", err)
+	}
+	return apiClient.WaitForDeletion(context.Background(), processRequestDatacenterDelete, datacenterID)
 }
 
 // createDcAndWaitUntilDone - creates datacenter and waits until provisioning is successful
-// return - datacenter object created, or error
+// return - datacenter object created, or error. The datacenter is also returned with the
+// error if the wait fails, so it can be cleaned up
 func (s *stepCreateServer) createDcAndWaitUntilDone(ctx context.Context, name, loc string) (*ionoscloud.Datacenter, error) {
 
 	//datacenterName := "testDatacenter"
@@ -214,14 +598,14 @@ func (s *stepCreateServer) createDcAndWaitUntilDone(ctx context.Context, name, l
 	// gets the Location Header value, where Request ID is stored, to interrogate the request status
 	requestPath := getRequestPath(apiResponse)
 	if requestPath == "" {
-		return nil, fmt.Errorf("error getting location from header for datacenter")
+		return &dc, fmt.Errorf("error getting location from header for datacenter")
 	}
 
 	// Waits for the datacenter creation to finish. Polls until it receives an answer that
 	// provisioning is successful
 	err = s.waitForRequestToBeDone(ctx, requestPath)
 	if err != nil {
-		return nil, fmt.Errorf("error while waiting for datacenter creation to finish (%w)", err)
+		return &dc, fmt.Errorf("error while waiting for datacenter creation to finish (%w)", err)
 	}
 	return &dc, nil
 }
@@ -257,7 +641,8 @@ func (s *stepCreateServer) waitForRequestToBeDone(ctx context.Context, path stri
 }
 
 // createServerAndWaitUntilDone - creates server and waits until provisioning is successful
-// return - server object created, or error
+// return - server object created, or error. The server is also returned with the
+// error if the wait fails, so it can be cleaned up
 func (s *stepCreateServer) createServerAndWaitUntilDone(ctx context.Context, dcId string, server ionoscloud.Server) (*ionoscloud.Server, error) {
 	server, apiResponse, err := s.createServer(ctx, dcId, server)
 	if err != nil {
@@ -269,13 +654,13 @@ func (s *stepCreateServer) createServerAndWaitUntilDone(ctx context.Context, dcI
 	// Gets path to interrogate server creation status
 	requestPath := getRequestPath(apiResponse)
 	if requestPath == "" {
-		return nil, fmt.Errorf("error getting server path")
+		return &server, fmt.Errorf("error getting server path")
 	}
 	// Waits for the server creation to finish. It takes some time to create
 	// a compute resource, so we poll until provisioning is successful
 	err = s.waitForRequestToBeDone(ctx, requestPath)
 	if err != nil {
-		return nil, fmt.Errorf("error while waiting for server creation to finish (%w)", err)
+		return &server, fmt.Errorf("error while waiting for server creation to finish (%w)", err)
 	}
 	return &server, nil
 }
@@ -285,7 +670,8 @@ func (s *stepCreateServer) createServer(ctx context.Context, datacenterId string
 }
 
 // createLanAndWaitUntilDone - creates LAN and waits until provisioning is successful
-// return - server object created, or error
+// return - server object created, or error. The server is also returned with the
+// error if the wait fails, so it can be cleaned up
 func (s *stepCreateServer) createLanAndWaitUntilDone(ctx context.Context, dcId string, lanPost ionoscloud.LanPost) (*ionoscloud.LanPost, error) {
 	lan, apiResponse, err := s.client.LANsApi.DatacentersLansPost(ctx, dcId).Lan(lanPost).Execute()
 	if err != nil {
@@ -297,13 +683,13 @@ func (s *stepCreateServer) createLanAndWaitUntilDone(ctx context.Context, dcId s
 	// Gets path to interrogate server creation status
 	requestPath := getRequestPath(apiResponse)
 	if requestPath == "" {
-		return nil, fmt.Errorf("error getting LAN path")
+		return &lan, fmt.Errorf("error getting LAN path")
 	}
 	// Waits for the server creation to finish. It takes some time to create
 	// a compute resource, so we poll until provisioning is successful
 	err = s.waitForRequestToBeDone(ctx, requestPath)
 	if err != nil {
-		return nil, fmt.Errorf("error while waiting for LAN creation to finish (%w)", err)
+		return &lan, fmt.Errorf("error while waiting for LAN creation to finish (%w)", err)
 	}
 	return &lan, nil
 }
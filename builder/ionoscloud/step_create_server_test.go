@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"strings"
+	"testing"
+
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+func testDatacenter(id, name, location string) ionoscloud.Datacenter {
+	return ionoscloud.Datacenter{
+		Id: ionoscloud.PtrString(id),
+		Properties: &ionoscloud.DatacenterProperties{
+			Name:     ionoscloud.PtrString(name),
+			Location: ionoscloud.PtrString(location),
+		},
+	}
+}
+
+func TestSelectDatacenter(t *testing.T) {
+	dcs := []ionoscloud.Datacenter{
+		testDatacenter("1", "packer-builds", "de/fra"),
+		testDatacenter("2", "packer-builds-old", "de/fra"),
+		testDatacenter("3", "packer-builds", "us/las"),
+	}
+
+	id, err := selectDatacenter(dcs, "packer-builds", "de/fra")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != "1" {
+		t.Fatalf("expected datacenter 1, got %s", id)
+	}
+
+	if _, err := selectDatacenter(dcs, "packer-builds", "de/txl"); err == nil || !strings.Contains(err.Error(), "3 (us/las)") {
+		t.Fatalf("should list the datacenters in other locations, got %v", err)
+	}
+
+	dcs = append(dcs, testDatacenter("4", "packer-builds", "de/fra"))
+	if _, err := selectDatacenter(dcs, "packer-builds", "de/fra"); err == nil {
+		t.Fatal("should have error for ambiguous name")
+	}
+
+	if _, err := selectDatacenter(dcs, "other", "de/fra"); err == nil {
+		t.Fatal("should have error for unknown name")
+	}
+}
+
+func TestSelectLan(t *testing.T) {
+	lan := func(id, name string) ionoscloud.Lan {
+		return ionoscloud.Lan{
+			Id:         ionoscloud.PtrString(id),
+			Properties: &ionoscloud.LanProperties{Name: ionoscloud.PtrString(name)},
+		}
+	}
+	lans := []ionoscloud.Lan{lan("1", "public"), lan("2", "builds")}
+
+	id, err := selectLan(lans, "builds")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != "2" {
+		t.Fatalf("expected LAN 2, got %s", id)
+	}
+
+	if _, err := selectLan(append(lans, lan("3", "builds")), "builds"); err == nil {
+		t.Fatal("should have error for ambiguous name")
+	}
+}
+
+func TestSelectTemplate(t *testing.T) {
+	template := func(id, name string) ionoscloud.Template {
+		return ionoscloud.Template{
+			Id:         ionoscloud.PtrString(id),
+			Properties: &ionoscloud.TemplateProperties{Name: ionoscloud.PtrString(name)},
+		}
+	}
+	templates := []ionoscloud.Template{template("1", "CUBES XS"), template("2", "CUBES S")}
+
+	id, err := selectTemplate(templates, "cubes s")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if id != "2" {
+		t.Fatalf("expected template 2, got %s", id)
+	}
+
+	if _, err := selectTemplate(templates, "CUBES XL"); err == nil {
+		t.Fatal("should have error for unknown name")
+	}
+}
+
+func TestServerIP(t *testing.T) {
+	nic := func(name string, ips ...string) ionoscloud.Nic {
+		return ionoscloud.Nic{
+			Properties: &ionoscloud.NicProperties{
+				Name: ionoscloud.PtrString(name),
+				Ips:  &ips,
+			},
+		}
+	}
+	server := &ionoscloud.Server{
+		Entities: &ionoscloud.ServerEntities{
+			Nics: &ionoscloud.Nics{
+				Items: &[]ionoscloud.Nic{nic("public", "203.0.113.10"), nic("private", "10.7.0.5")},
+			},
+		},
+	}
+
+	ip, err := serverIP(server, "private")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ip != "10.7.0.5" {
+		t.Fatalf("expected the IP of the private NIC, got %s", ip)
+	}
+
+	if _, err := serverIP(server, "other"); err == nil {
+		t.Fatal("should have error for an unknown NIC")
+	}
+}
+
+func TestNewNic(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["firewall_active"] = true
+	config["firewall_source_cidrs"] = []string{"192.0.2.0/24"}
+	config["nic"] = []map[string]interface{}{
+		{"name": "public"},
+		{"name": "internal", "ips": []string{"10.7.0.5"}},
+	}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	c := &b.config
+
+	nic := newNic(c, c.Nics[0], 1, "", "203.0.113.10")
+	if ips := *nic.Properties.Ips; len(ips) != 1 || ips[0] != "203.0.113.10" {
+		t.Fatalf("communicator NIC should get the reserved IP: %v", ips)
+	}
+	if rules := *nic.Entities.Firewallrules.Items; len(rules) != 1 {
+		t.Fatalf("communicator NIC should allow the communicator port: %d rules", len(rules))
+	}
+
+	nic = newNic(c, c.Nics[1], 2, "", "203.0.113.10")
+	if ips := *nic.Properties.Ips; len(ips) != 1 || ips[0] != "10.7.0.5" {
+		t.Fatalf("bad IPs: %v", ips)
+	}
+	if *nic.Properties.Lan != 2 || !*nic.Properties.FirewallActive {
+		t.Fatalf("bad NIC properties: %d %t", *nic.Properties.Lan, *nic.Properties.FirewallActive)
+	}
+	if rules := *nic.Entities.Firewallrules.Items; len(rules) != 0 {
+		t.Fatalf("other NICs should not open the communicator port: %d rules", len(rules))
+	}
+}
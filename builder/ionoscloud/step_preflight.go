@@ -0,0 +1,308 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// stepPreflight checks the configuration against the capabilities of the
+// location and resolves the source image before anything is created, so
+// mistakes fail the build before any resource is billed. All problems found
+// are reported at once.
+type stepPreflight struct {
+	client        *ionoscloud.APIClient
+	generatedData *packerbuilderdata.GeneratedData
+}
+
+func newStepPreflight(client *ionoscloud.APIClient, generatedData *packerbuilderdata.GeneratedData) *stepPreflight {
+	return &stepPreflight{
+		client:        client,
+		generatedData: generatedData,
+	}
+}
+
+func (s *stepPreflight) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	ui.Say("Validating the configuration against the location...")
+
+	location, err := s.getLocation(ctx, c.Region)
+	if err != nil {
+		return s.halt(state, ui, &packersdk.MultiError{Errors: []error{err}})
+	}
+
+	errs := &packersdk.MultiError{}
+	errs = packersdk.MultiErrorAppend(errs, checkLocation(location, c)...)
+
+	var names []string
+	var existing map[string][]string
+	if !c.SkipCreateSnapshot {
+		names = c.snapshotNames()
+		existing, err = s.existingSnapshots(ctx, c.Region, names)
+	}
+	switch {
+	case err != nil:
+		errs = packersdk.MultiErrorAppend(errs, err)
+	case len(existing) == 0:
+	case c.SkipIfExists && len(existing) == len(names):
+		ui.Say(fmt.Sprintf("Snapshot %s already exists, skipping the build", c.SnapshotName))
+		state.Put("snapshot_exists", true)
+		return multistep.ActionHalt
+	case c.ForceDeleteSnapshot:
+		var ids []string
+		for _, name := range names {
+			for _, id := range existing[name] {
+				ui.Say(fmt.Sprintf("Snapshot %s (%s) will be replaced", name, id))
+				ids = append(ids, id)
+			}
+		}
+		state.Put("replaced_snapshots", ids)
+	default:
+		for _, name := range names {
+			if len(existing[name]) > 0 {
+				errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+					"snapshot %s already exists in %s, set 'force_delete_snapshot' to replace it", name, c.Region))
+			}
+		}
+	}
+
+	src, err := s.resolveSourceImage(ctx, c, location)
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("error getting image: %w", err))
+	} else {
+		errs = packersdk.MultiErrorAppend(errs, checkSourceImage(src, c)...)
+	}
+
+	if len(errs.Errors) > 0 {
+		return s.halt(state, ui, errs)
+	}
+
+	state.Put("source_image", src)
+	state.Put("source_image_id", src.Id)
+	state.Put("source_image_name", src.Name)
+	s.generatedData.Put("SourceImageID", src.Id)
+	s.generatedData.Put("SourceImageName", src.Name)
+	s.generatedData.Put("SourceType", src.Type)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepPreflight) Cleanup(_ multistep.StateBag) {
+}
+
+func (s *stepPreflight) halt(state multistep.StateBag, ui packersdk.Ui, errs *packersdk.MultiError) multistep.StepAction {
+	err := fmt.Errorf("preflight check failed: %w", errs)
+	state.Put("error", err)
+	ui.Error(err.Error())
+	return multistep.ActionHalt
+}
+
+// getLocation returns the location called name, e.g. de/fra.
+func (s *stepPreflight) getLocation(ctx context.Context, name string) (ionoscloud.Location, error) {
+	locations, _, err := s.client.LocationsApi.LocationsGet(ctx).Depth(1).Execute()
+	if err != nil {
+		return ionoscloud.Location{}, fmt.Errorf("error getting locations: %w", err)
+	}
+	if locations.Items == nil {
+		return ionoscloud.Location{}, fmt.Errorf("no locations returned by the API")
+	}
+	return selectLocation(*locations.Items, name)
+}
+
+// selectLocation returns the location with the ID name.
+func selectLocation(locations []ionoscloud.Location, name string) (ionoscloud.Location, error) {
+	var ids []string
+	for _, l := range locations {
+		if stringValue(l.Id) == name {
+			return l, nil
+		}
+		ids = append(ids, stringValue(l.Id))
+	}
+	sort.Strings(ids)
+	return ionoscloud.Location{}, fmt.Errorf("location %q does not exist, available locations: %s",
+		name, strings.Join(ids, ", "))
+}
+
+// existingSnapshots returns the IDs of the snapshots in location that are
+// called one of names, by name.
+func (s *stepPreflight) existingSnapshots(ctx context.Context, location string, names []string) (map[string][]string, error) {
+	snapshots, _, err := s.client.SnapshotsApi.SnapshotsGet(ctx).Filter("location", location).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("error getting snapshots: %w", err)
+	}
+	if snapshots.Items == nil {
+		return nil, nil
+	}
+	resources := make([]ImageResource, 0, len(*snapshots.Items))
+	for _, snapshot := range *snapshots.Items {
+		resources = append(resources, imageResourceFromSnapshot(snapshot))
+	}
+	return snapshotsByName(resources, location, names), nil
+}
+
+// snapshotsByName returns the IDs of the snapshots in location that are
+// called one of names, by name.
+func snapshotsByName(snapshots []ImageResource, location string, names []string) map[string][]string {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	found := make(map[string][]string)
+	for _, snapshot := range snapshots {
+		// the location filter of the API matches substrings
+		if snapshot.Location == location && wanted[snapshot.Name] {
+			found[snapshot.Name] = append(found[snapshot.Name], snapshot.Id)
+		}
+	}
+	return found
+}
+
+// checkLocation checks the server and volume settings against the CPU
+// architectures and features offered by location.
+func checkLocation(location ionoscloud.Location, c *Config) []error {
+	var errs []error
+	props := location.Properties
+	if props == nil {
+		return nil
+	}
+
+	// a CUBE server gets its cores and RAM from the template, a VCPU server
+	// is not bound to a CPU family
+	if props.CpuArchitecture != nil && c.ServerType != serverTypeCube {
+		var families []string
+		var maxCores, maxRam int32
+		for _, arch := range *props.CpuArchitecture {
+			family := stringValue(arch.CpuFamily)
+			families = append(families, family)
+			if c.CpuFamily != "" && family != c.CpuFamily {
+				continue
+			}
+			if arch.MaxCores != nil && *arch.MaxCores > maxCores {
+				maxCores = *arch.MaxCores
+			}
+			if arch.MaxRam != nil && *arch.MaxRam > maxRam {
+				maxRam = *arch.MaxRam
+			}
+		}
+		sort.Strings(families)
+
+		if c.CpuFamily != "" && !containsString(families, c.CpuFamily) {
+			errs = append(errs, fmt.Errorf("'cpu_family' %s is not available in %s, available CPU families: %s",
+				c.CpuFamily, c.Region, strings.Join(families, ", ")))
+		} else {
+			if maxCores > 0 && c.Cores > maxCores {
+				errs = append(errs, fmt.Errorf("'cores' %d exceeds the maximum of %d in %s", c.Cores, maxCores, c.Region))
+			}
+			if maxRam > 0 && c.Ram > maxRam {
+				errs = append(errs, fmt.Errorf("'ram' %d exceeds the maximum of %d MB in %s", c.Ram, maxRam, c.Region))
+			}
+		}
+	}
+
+	// only locations that report their features are checked for SSD support
+	if props.Features != nil && len(*props.Features) > 0 && !containsString(*props.Features, "SSD") {
+		if strings.HasPrefix(c.DiskType, "SSD") {
+			errs = append(errs, fmt.Errorf("'disk_type' %s is not available in %s", c.DiskType, c.Region))
+		}
+		for _, v := range c.Volumes {
+			if strings.HasPrefix(v.Type, "SSD") {
+				errs = append(errs, fmt.Errorf("volume %s: 'type' %s is not available in %s", v.Name, v.Type, c.Region))
+			}
+		}
+	}
+	return errs
+}
+
+// checkSourceImage checks that the build volume can be created from src in
+// the configured location.
+func checkSourceImage(src *sourceImage, c *Config) []error {
+	var errs []error
+	if src.Location != "" && src.Location != c.Region {
+		errs = append(errs, fmt.Errorf("%s %s is in %s, not in %s", src.Type, src.Name, src.Location, c.Region))
+	}
+	if src.ImageType != "" && src.ImageType != "HDD" {
+		errs = append(errs, fmt.Errorf("%s %s is of type %s, a volume can only be created from an HDD image", src.Type, src.Name, src.ImageType))
+	}
+	if c.DiskSize != 0 && src.Size > c.DiskSize {
+		errs = append(errs, fmt.Errorf("'disk_size' %v is smaller than the %v GB of %s %s", c.DiskSize, src.Size, src.Type, src.Name))
+	}
+	return errs
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSourceImage works out what the build volume is created from: an
+// image alias offered by the location, a snapshot or an image, the latter two
+// given by UUID or looked up by name, or an image matching image_filter.
+func (s *stepPreflight) resolveSourceImage(ctx context.Context, c *Config, location ionoscloud.Location) (*sourceImage, error) {
+	if c.ImageAlias != "" {
+		if err := checkImageAlias(location, c.Region, c.ImageAlias); err != nil {
+			return nil, err
+		}
+		return &sourceImage{Alias: c.ImageAlias, Name: c.ImageAlias, Type: sourceTypeAlias, Public: true}, nil
+	}
+
+	if c.SourceSnapshot != "" {
+		snapshot, err := s.getSnapshot(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		return &sourceImage{Id: snapshot.Id, Name: snapshot.Name, Type: sourceTypeSnapshot,
+			Location: snapshot.Location, Size: snapshot.Size}, nil
+	}
+
+	img, err := s.getImage(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return &sourceImage{Id: img.Id, Name: img.Name, Type: sourceTypeImage, Public: img.Public,
+		Location: img.Location, ImageType: img.ImageType, Size: img.Size}, nil
+}
+
+func (s *stepPreflight) getImage(ctx context.Context, c *Config) (*ImageResource, error) {
+	if !c.ImageFilter.empty() {
+		return findImage(ctx, s.client, c.ImageFilter.query(c.Region))
+	}
+
+	if isUUID(c.Image) {
+		img, _, err := s.client.ImagesApi.ImagesFindById(ctx, c.Image).Execute()
+		if err != nil {
+			return nil, fmt.Errorf("error getting image %s: %w", c.Image, err)
+		}
+		r := imageResourceFromImage(img)
+		return &r, nil
+	}
+
+	return findImage(ctx, s.client, c.imageQuery(c.Image))
+}
+
+func (s *stepPreflight) getSnapshot(ctx context.Context, c *Config) (*ImageResource, error) {
+	if isUUID(c.SourceSnapshot) {
+		snapshot, _, err := s.client.SnapshotsApi.SnapshotsFindById(ctx, c.SourceSnapshot).Execute()
+		if err != nil {
+			return nil, fmt.Errorf("error getting snapshot %s: %w", c.SourceSnapshot, err)
+		}
+		r := imageResourceFromSnapshot(snapshot)
+		return &r, nil
+	}
+
+	return findSnapshot(ctx, s.client, c.imageQuery(c.SourceSnapshot))
+}
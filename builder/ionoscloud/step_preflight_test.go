@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"strings"
+	"testing"
+
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+func testLocation() ionoscloud.Location {
+	arch := func(family string, cores, ram int32) ionoscloud.CpuArchitectureProperties {
+		return ionoscloud.CpuArchitectureProperties{
+			CpuFamily: ionoscloud.PtrString(family),
+			MaxCores:  ionoscloud.PtrInt32(cores),
+			MaxRam:    ionoscloud.PtrInt32(ram),
+		}
+	}
+	return ionoscloud.Location{
+		Id: ionoscloud.PtrString("de/fra"),
+		Properties: &ionoscloud.LocationProperties{
+			CpuArchitecture: &[]ionoscloud.CpuArchitectureProperties{
+				arch("INTEL_SKYLAKE", 62, 235520),
+				arch("AMD_OPTERON", 8, 16384),
+			},
+			Features: &[]string{"cloud-init"},
+		},
+	}
+}
+
+func TestSelectLocation(t *testing.T) {
+	locations := []ionoscloud.Location{
+		{Id: ionoscloud.PtrString("us/las")},
+		testLocation(),
+	}
+
+	location, err := selectLocation(locations, "de/fra")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if *location.Id != "de/fra" {
+		t.Fatalf("expected de/fra, got %s", *location.Id)
+	}
+
+	_, err = selectLocation(locations, "de/fr")
+	if err == nil {
+		t.Fatal("should have error for unknown location")
+	}
+	if !strings.Contains(err.Error(), "available locations: de/fra, us/las") {
+		t.Fatalf("error should list the available locations: %s", err)
+	}
+}
+
+func TestCheckLocation(t *testing.T) {
+	c := &Config{Region: "de/fra", ServerType: serverTypeEnterprise, Cores: 4, Ram: 2048, DiskType: "HDD"}
+	if errs := checkLocation(testLocation(), c); len(errs) != 0 {
+		t.Fatalf("should not have errors: %v", errs)
+	}
+
+	c.CpuFamily = "AMD_OPTERON"
+	c.Cores = 16
+	c.Ram = 32768
+	c.DiskType = "SSD"
+	c.Volumes = []Volume{{Name: "data", Type: "SSD Premium"}}
+	errs := checkLocation(testLocation(), c)
+	if len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got %v", errs)
+	}
+
+	c.CpuFamily = "INTEL_ICELAKE"
+	errs = checkLocation(testLocation(), c)
+	if len(errs) == 0 || !strings.Contains(errs[0].Error(), "available CPU families: AMD_OPTERON, INTEL_SKYLAKE") {
+		t.Fatalf("should have error for unknown CPU family: %v", errs)
+	}
+
+	cube := &Config{Region: "de/fra", ServerType: serverTypeCube, DiskType: "DAS"}
+	if errs := checkLocation(testLocation(), cube); len(errs) != 0 {
+		t.Fatalf("a CUBE server should not be checked against the CPU limits: %v", errs)
+	}
+}
+
+func TestCheckSourceImage(t *testing.T) {
+	c := &Config{Region: "de/fra", DiskSize: 10}
+	src := &sourceImage{Name: "ubuntu", Type: sourceTypeImage, Location: "de/fra", ImageType: "HDD", Size: 5}
+	if errs := checkSourceImage(src, c); len(errs) != 0 {
+		t.Fatalf("should not have errors: %v", errs)
+	}
+
+	src = &sourceImage{Name: "ubuntu", Type: sourceTypeImage, Location: "us/las", ImageType: "CDROM", Size: 20}
+	if errs := checkSourceImage(src, c); len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+
+	alias := &sourceImage{Alias: "ubuntu:latest", Name: "ubuntu:latest", Type: sourceTypeAlias}
+	if errs := checkSourceImage(alias, c); len(errs) != 0 {
+		t.Fatalf("an image alias should not be checked: %v", errs)
+	}
+}
+
+func TestSnapshotsByName(t *testing.T) {
+	snapshots := []ImageResource{
+		{Id: "1", Name: "packer", Location: "de/fra"},
+		{Id: "2", Name: "packer", Location: "de/fra"},
+		{Id: "3", Name: "packer", Location: "de/fra/2"},
+		{Id: "4", Name: "packer-data", Location: "de/fra"},
+		{Id: "5", Name: "other", Location: "de/fra"},
+	}
+
+	found := snapshotsByName(snapshots, "de/fra", []string{"packer", "packer-data", "packer-logs"})
+	if len(found) != 2 || len(found["packer"]) != 2 || found["packer-data"][0] != "4" {
+		t.Fatalf("bad snapshots: %v", found)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+// stepReserveIp reserves a temporary IP block, or picks a free IP of an
+// existing one, for the NIC of the build server.
+type stepReserveIp struct {
+	client        *ionoscloud.APIClient
+	generatedData *packerbuilderdata.GeneratedData
+}
+
+func newStepReserveIp(client *ionoscloud.APIClient, generatedData *packerbuilderdata.GeneratedData) *stepReserveIp {
+	return &stepReserveIp{
+		client:        client,
+		generatedData: generatedData,
+	}
+}
+
+func (s *stepReserveIp) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if !c.ReserveIp && c.IpBlockId == "" {
+		return multistep.ActionContinue
+	}
+
+	var block ionoscloud.IpBlock
+	var err error
+	if c.IpBlockId != "" {
+		ui.Say("Using existing IP block...")
+		block, _, err = s.client.IPBlocksApi.IpblocksFindById(ctx, c.IpBlockId).Execute()
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while getting the IP block %s", err.Error()))
+			return multistep.ActionHalt
+		}
+		// the IPs of a block can only be assigned to servers in its location
+		var location string
+		if block.Properties != nil {
+			location = stringValue(block.Properties.Location)
+		}
+		if location != c.Region {
+			ui.Error(fmt.Sprintf("IP block %s is in location %q, not in %q", c.IpBlockId, location, c.Region))
+			return multistep.ActionHalt
+		}
+		state.Put("ip_block_id", c.IpBlockId)
+	} else {
+		ui.Say("Reserving IP block...")
+		created, err := s.createIpBlock(ctx, c.SnapshotName, c.Region)
+		if created.Id != nil {
+			// only a block reserved by the build is released on cleanup,
+			// record it before anything else can fail
+			state.Put("ip_block_created", true)
+			state.Put("ip_block_id", *created.Id)
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while reserving an IP block %s", err.Error()))
+			return multistep.ActionHalt
+		}
+		err = addLabels(c.RunLabels, func(l ionoscloud.LabelResource) error {
+			_, _, err := s.client.LabelsApi.IpblocksLabelsPost(ctx, *created.Id).Label(l).Execute()
+			return err
+		})
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while labelling the IP block %s", err.Error()))
+			return multistep.ActionHalt
+		}
+		// the IPs are only known once the block is provisioned
+		block, _, err = s.client.IPBlocksApi.IpblocksFindById(ctx, *created.Id).Execute()
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error occurred while getting the IP block %s", err.Error()))
+			return multistep.ActionHalt
+		}
+	}
+
+	ip, err := freeIp(block)
+	if err != nil {
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	state.Put("reserved_ip", ip)
+	s.generatedData.Put("IPBlockID", stringValue(block.Id))
+	s.generatedData.Put("ReservedIP", ip)
+	ui.Say(fmt.Sprintf("Using IP %s", ip))
+
+	return multistep.ActionContinue
+}
+
+func (s *stepReserveIp) Cleanup(state multistep.StateBag) {
+	if _, created := state.GetOk("ip_block_created"); !created {
+		return
+	}
+	ipBlockId, ok := state.GetOk("ip_block_id")
+	if !ok {
+		return
+	}
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say("Releasing IP block...")
+	ctx := context.Background()
+	apiResponse, err := s.client.IPBlocksApi.IpblocksDelete(ctx, ipBlockId.(string)).Execute()
+	if err == nil {
+		if requestPath := getRequestPath(apiResponse); requestPath != "" {
+			_, err = s.client.WaitForRequest(ctx, requestPath)
+		}
+	}
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error releasing IP block %s. Please release it manually: %s", ipBlockId, err))
+	}
+}
+
+// createIpBlock reserves a single IP in location and waits until it is
+// available. The block is returned along with any error once the API has
+// accepted it, so that the caller can release it.
+func (s *stepReserveIp) createIpBlock(ctx context.Context, name, location string) (ionoscloud.IpBlock, error) {
+	block, apiResponse, err := s.client.IPBlocksApi.IpblocksPost(ctx).Ipblock(ionoscloud.IpBlock{
+		Properties: &ionoscloud.IpBlockProperties{
+			Name:     ionoscloud.PtrString(name),
+			Location: ionoscloud.PtrString(location),
+			Size:     ionoscloud.PtrInt32(1),
+		},
+	}).Execute()
+	if err != nil {
+		return block, fmt.Errorf("error creating IP block (%w)", err)
+	}
+	requestPath := getRequestPath(apiResponse)
+	if requestPath == "" {
+		return block, errors.New("error getting IP block path")
+	}
+	if _, err := s.client.WaitForRequest(ctx, requestPath); err != nil {
+		return block, fmt.Errorf("error while waiting for IP block creation to finish (%w)", err)
+	}
+	return block, nil
+}
+
+// freeIp returns the first IP of block that is not in use.
+func freeIp(block ionoscloud.IpBlock) (string, error) {
+	props := block.Properties
+	if props == nil || props.Ips == nil {
+		return "", fmt.Errorf("IP block %s has no IPs", stringValue(block.Id))
+	}
+	used := make(map[string]bool)
+	if props.IpConsumers != nil {
+		for _, consumer := range *props.IpConsumers {
+			used[stringValue(consumer.Ip)] = true
+		}
+	}
+	for _, ip := range *props.Ips {
+		if !used[ip] {
+			return ip, nil
+		}
+	}
+	return "", fmt.Errorf("all IPs of IP block %s are in use", stringValue(block.Id))
+}
@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+func TestFreeIp(t *testing.T) {
+	block := ionoscloud.IpBlock{
+		Id: ionoscloud.PtrString("block"),
+		Properties: &ionoscloud.IpBlockProperties{
+			Ips: &[]string{"203.0.113.10", "203.0.113.11"},
+			IpConsumers: &[]ionoscloud.IpConsumer{
+				{Ip: ionoscloud.PtrString("203.0.113.10")},
+			},
+		},
+	}
+
+	ip, err := freeIp(block)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ip != "203.0.113.11" {
+		t.Fatalf("expected the unused IP, got %s", ip)
+	}
+
+	consumers := append(*block.Properties.IpConsumers, ionoscloud.IpConsumer{Ip: ionoscloud.PtrString("203.0.113.11")})
+	block.Properties.IpConsumers = &consumers
+	if _, err := freeIp(block); err == nil {
+		t.Fatal("should have error when all IPs are in use")
+	}
+}
+
+func TestStepReserveIp_ReleasesFailedBlock(t *testing.T) {
+	var deleted []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost:
+			w.Header().Set("Location", srv.URL+"/requests/request-id/status")
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{"id": "block-id"}`)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			fmt.Fprint(w, `{"id": "request-id", "metadata": {"status": "FAILED", "message": "no IPs left"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	state := new(multistep.BasicStateBag)
+	step := newStepReserveIp(ionoscloud.NewAPIClient(ionoscloud.NewConfiguration("", "", "token", srv.URL)),
+		&packerbuilderdata.GeneratedData{State: state})
+	state.Put("ui", packersdk.TestUi(t))
+	state.Put("config", &Config{SnapshotName: "packer", Region: "de/fra", ReserveIp: true})
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("should halt when the IP block fails to provision: %v", action)
+	}
+	step.Cleanup(state)
+	if len(deleted) != 1 || deleted[0] != "/cloudapi/v6/ipblocks/block-id" {
+		t.Fatalf("the failed IP block should be released: %v", deleted)
+	}
+}
+
+func TestStepReserveIp_BlockLocation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "block-id", "properties": {"location": "de/txl", "ips": ["203.0.113.10"]}}`)
+	}))
+	defer srv.Close()
+
+	state := new(multistep.BasicStateBag)
+	step := newStepReserveIp(ionoscloud.NewAPIClient(ionoscloud.NewConfiguration("", "", "token", srv.URL)),
+		&packerbuilderdata.GeneratedData{State: state})
+	state.Put("ui", packersdk.TestUi(t))
+	state.Put("config", &Config{Region: "de/fra", IpBlockId: "block-id"})
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("should halt for an IP block in another location: %v", action)
+	}
+
+	state.Put("config", &Config{Region: "de/txl", IpBlockId: "block-id"})
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("should use the IP block in the build location: %v", action)
+	}
+	if ip := state.Get("reserved_ip"); ip != "203.0.113.10" {
+		t.Fatalf("bad reserved IP: %v", ip)
+	}
+}
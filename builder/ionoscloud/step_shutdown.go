@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+const (
+	shutdownBehaviorNone    = "none"
+	shutdownBehaviorCommand = "command"
+	shutdownBehaviorStop    = "stop"
+
+	vmStateShutoff = "SHUTOFF"
+
+	// shutdownPollInterval is how often the VM state is checked while
+	// waiting for the server to power off.
+	shutdownPollInterval = 5 * time.Second
+)
+
+// stepShutdown powers off the build server before the snapshot is taken, so
+// the snapshot is of a cleanly unmounted file system. A graceful shutdown
+// that does not finish in time falls back to stopping the server.
+type stepShutdown struct {
+	client *ionoscloud.APIClient
+}
+
+func newStepShutdown(client *ionoscloud.APIClient) *stepShutdown {
+	return &stepShutdown{
+		client: client,
+	}
+}
+
+func (s *stepShutdown) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	c := state.Get("config").(*Config)
+
+	if c.ShutdownBehavior == shutdownBehaviorNone {
+		return multistep.ActionContinue
+	}
+
+	dcId := state.Get("datacenter_id").(string)
+	serverId := state.Get("instance_id").(string)
+
+	stop := c.ShutdownBehavior == shutdownBehaviorStop
+	if c.ShutdownBehavior == shutdownBehaviorCommand {
+		ui.Say("Gracefully shutting down the server...")
+		comm, _ := state.Get("communicator").(packersdk.Communicator)
+		if comm == nil {
+			ui.Error("no communicator found")
+			return multistep.ActionHalt
+		}
+		// the connection usually drops while the command runs, so neither
+		// the command nor its exit status are waited for
+		cmd := &packersdk.RemoteCmd{Command: c.ShutdownCommand}
+		if err := comm.Start(ctx, cmd); err != nil {
+			ui.Error(fmt.Sprintf("Error sending the shutdown command: %s", err.Error()))
+		}
+
+		err := s.waitForShutoff(ctx, dcId, serverId, c.ShutdownTimeout)
+		switch {
+		case err == nil:
+		case errors.Is(err, context.DeadlineExceeded) && c.ServerType != serverTypeCube:
+			ui.Say(fmt.Sprintf("The server did not shut down within %s, stopping it", c.ShutdownTimeout))
+			stop = true
+		default:
+			ui.Error(fmt.Sprintf("Error waiting for the server to shut down: %s", err.Error()))
+			return multistep.ActionHalt
+		}
+	}
+
+	if stop {
+		ui.Say("Stopping the server...")
+		if err := s.stopServer(ctx, dcId, serverId); err != nil {
+			ui.Error(fmt.Sprintf("Error stopping the server: %s", err.Error()))
+			return multistep.ActionHalt
+		}
+		if err := s.waitForShutoff(ctx, dcId, serverId, c.ShutdownTimeout); err != nil {
+			ui.Error(fmt.Sprintf("Error waiting for the server to stop: %s", err.Error()))
+			return multistep.ActionHalt
+		}
+	}
+
+	ui.Say("The server is shut off")
+	state.Put("server_stopped", true)
+	return multistep.ActionContinue
+}
+
+func (s *stepShutdown) Cleanup(_ multistep.StateBag) {
+}
+
+func (s *stepShutdown) stopServer(ctx context.Context, dcId, serverId string) error {
+	apiResponse, err := s.client.ServersApi.DatacentersServersStopPost(ctx, dcId, serverId).Execute()
+	if err != nil {
+		return err
+	}
+	requestPath := getRequestPath(apiResponse)
+	if requestPath == "" {
+		return errors.New("error getting location from header for server stop")
+	}
+	_, err = s.client.WaitForRequest(ctx, requestPath)
+	return err
+}
+
+// waitForShutoff polls the VM state of the server until it is SHUTOFF. It
+// returns an error wrapping context.DeadlineExceeded if that takes longer
+// than timeout.
+func (s *stepShutdown) waitForShutoff(ctx context.Context, dcId, serverId string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		server, _, err := s.client.ServersApi.DatacentersServersFindById(ctx, dcId, serverId).Execute()
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("error getting server %s: %w", serverId, err)
+		}
+		if err == nil && isShutoff(server) {
+			return nil
+		}
+		log.Printf("waiting for server %s to shut off", serverId)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("server %s is not shut off: %w", serverId, ctx.Err())
+		case <-time.After(shutdownPollInterval):
+		}
+	}
+}
+
+func isShutoff(server ionoscloud.Server) bool {
+	return server.Properties != nil && stringValue(server.Properties.VmState) == vmStateShutoff
+}
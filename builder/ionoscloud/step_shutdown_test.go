@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+func TestWaitForShutoff(t *testing.T) {
+	vmState := "RUNNING"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "server-id", "properties": {"vmState": %q}}`, vmState)
+	}))
+	defer srv.Close()
+
+	step := newStepShutdown(ionoscloud.NewAPIClient(ionoscloud.NewConfiguration("", "", "token", srv.URL)))
+
+	err := step.waitForShutoff(context.Background(), "dc-id", "server-id", 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("should time out while the server is running: %v", err)
+	}
+
+	vmState = vmStateShutoff
+	if err := step.waitForShutoff(context.Background(), "dc-id", "server-id", time.Second); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -12,16 +12,20 @@ import (
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
 	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
 )
 
 type stepTakeSnapshot struct {
-	client *ionoscloud.APIClient
+	client        *ionoscloud.APIClient
+	generatedData *packerbuilderdata.GeneratedData
 }
 
-func newStepTakeSnapshot(client *ionoscloud.APIClient) *stepTakeSnapshot {
+func newStepTakeSnapshot(client *ionoscloud.APIClient, generatedData *packerbuilderdata.GeneratedData) *stepTakeSnapshot {
 	return &stepTakeSnapshot{
-		client: client,
+		client:        client,
+		generatedData: generatedData,
 	}
 }
 
@@ -35,38 +39,55 @@ func (s *stepTakeSnapshot) Run(ctx context.Context, state multistep.StateBag) mu
 	volumeId := state.Get("volume_id").(string)
 	serverId := state.Get("instance_id").(string)
 
-	comm, _ := state.Get("communicator").(packersdk.Communicator)
-	if comm == nil {
-		ui.Error("no communicator found")
-		return multistep.ActionHalt
+	// a server that was shut down has already written all changes to disk
+	if _, stopped := state.GetOk("server_stopped"); !stopped {
+		comm, _ := state.Get("communicator").(packersdk.Communicator)
+		if comm == nil {
+			ui.Error("no communicator found")
+			return multistep.ActionHalt
+		}
+
+		/* sync fs changes from the provisioning step */
+		os, err := s.getOs(ctx, dcId, serverId)
+		if err != nil {
+			ui.Error(fmt.Sprintf("an error occurred while getting the server os: %s", err.Error()))
+			return multistep.ActionHalt
+		}
+		ui.Say(fmt.Sprintf("Server OS is %s", os))
+
+		switch strings.ToLower(os) {
+		case "linux":
+			ui.Say("syncing file system changes")
+			if err := s.syncFs(ctx, comm); err != nil {
+				ui.Error(fmt.Sprintf("error syncing fs changes: %s", err.Error()))
+				return multistep.ActionHalt
+			}
+		}
 	}
 
-	/* sync fs changes from the provisioning step */
-	os, err := s.getOs(ctx, dcId, serverId)
+	description, err := renderSnapshotDescription(c, state)
 	if err != nil {
-		ui.Error(fmt.Sprintf("an error occurred while getting the server os: %s", err.Error()))
+		ui.Error(fmt.Sprintf("Error rendering the snapshot description: %s", err.Error()))
 		return multistep.ActionHalt
 	}
-	ui.Say(fmt.Sprintf("Server OS is %s", os))
-
-	switch strings.ToLower(os) {
-	case "linux":
-		ui.Say("syncing file system changes")
-		if err := s.syncFs(ctx, comm); err != nil {
-			ui.Error(fmt.Sprintf("error syncing fs changes: %s", err.Error()))
-			return multistep.ActionHalt
-		}
+	opts := snapshotOptions{
+		Description:       description,
+		LicenceType:       c.SnapshotLicenceType,
+		SecAuthProtection: c.SnapshotSecAuthProtection,
 	}
 
 	ui.Say(fmt.Sprintf("Creating a snapshot for %s/volumes/%s", dcId, volumeId))
-	snapshot, err := s.createSnapshot(ctx, dcId, volumeId)
+	snapshot, err := s.createSnapshot(ctx, dcId, volumeId, c.SnapshotName, opts)
+	if snapshot != nil {
+		state.Put("snapshotname", c.SnapshotName)
+		state.Put("snapshot_id", *snapshot.Id)
+		s.generatedData.Put("SnapshotID", *snapshot.Id)
+	}
 	if err != nil {
 		ui.Error(fmt.Sprintf("An error occurred while creating a snapshot: %s", err.Error()))
 		return multistep.ActionHalt
 	}
 
-	state.Put("snapshotname", c.SnapshotName)
-
 	ui.Say("Waiting until snapshot available snapshot")
 
 	err = s.waitTillSnapshotAvailable(*snapshot.Id, ui)
@@ -75,10 +96,94 @@ func (s *stepTakeSnapshot) Run(ctx context.Context, state multistep.StateBag) mu
 		return multistep.ActionHalt
 	}
 
+	if err := s.labelSnapshot(ctx, c, *snapshot.Id); err != nil {
+		ui.Error(fmt.Sprintf("An error occurred while labelling the snapshot: %s", err.Error()))
+		return multistep.ActionHalt
+	}
+
+	// the capabilities can only be set once the snapshot is available
+	if props := snapshotCapabilities(c); props != nil {
+		ui.Say("Setting the snapshot capabilities...")
+		if err := s.patchSnapshot(ctx, *snapshot.Id, *props); err != nil {
+			ui.Error(fmt.Sprintf("An error occurred while setting the snapshot capabilities: %s", err.Error()))
+			return multistep.ActionHalt
+		}
+	}
+
+	dataVolumes, _ := state.Get("data_volumes").([]dataVolume)
+	var dataSnapshots []artifactSnapshot
+	var dataSnapshotIds []string
+	for _, v := range dataVolumes {
+		if v.SnapshotName == "" {
+			continue
+		}
+		ui.Say(fmt.Sprintf("Creating a snapshot of data volume %s", v.Name))
+		// the licence type and capabilities only matter for the boot volume
+		snapshot, err := s.createSnapshot(ctx, dcId, v.Id, v.SnapshotName, snapshotOptions{
+			Description:       description,
+			SecAuthProtection: c.SnapshotSecAuthProtection,
+		})
+		if snapshot != nil {
+			dataSnapshots = append(dataSnapshots, artifactSnapshot{id: *snapshot.Id, name: v.SnapshotName, volume: v.Name})
+			dataSnapshotIds = append(dataSnapshotIds, *snapshot.Id)
+			// record the snapshots right away, so Cleanup deletes them if a
+			// later snapshot or its labels fail
+			state.Put("data_snapshots", dataSnapshots)
+			s.generatedData.Put("DataSnapshotIDs", strings.Join(dataSnapshotIds, ","))
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("An error occurred while creating a snapshot of %s: %s", v.Name, err.Error()))
+			return multistep.ActionHalt
+		}
+
+		if err := s.waitTillSnapshotAvailable(*snapshot.Id, ui); err != nil {
+			ui.Error(fmt.Sprintf("An error occurred while waiting for the snapshot of %s to be created: %s", v.Name, err.Error()))
+			return multistep.ActionHalt
+		}
+		if err := s.labelSnapshot(ctx, c, *snapshot.Id); err != nil {
+			ui.Error(fmt.Sprintf("An error occurred while labelling the snapshot of %s: %s", v.Name, err.Error()))
+			return multistep.ActionHalt
+		}
+	}
+
+	// snapshots of the same name are only deleted once all new snapshots
+	// are available, failing to delete them does not fail the build
+	replaced, _ := state.Get("replaced_snapshots").([]string)
+	for _, id := range replaced {
+		ui.Say(fmt.Sprintf("Deleting replaced snapshot %s", id))
+		if err := deleteSnapshot(ctx, s.client, id); err != nil {
+			ui.Error(fmt.Sprintf("An error occurred while deleting the replaced snapshot: %s", err.Error()))
+		}
+	}
+
 	return multistep.ActionContinue
 }
 
-func (s *stepTakeSnapshot) Cleanup(_ multistep.StateBag) {
+// Cleanup deletes the snapshots taken by a build that failed or was
+// cancelled, so a rerun does not find them.
+func (s *stepTakeSnapshot) Cleanup(state multistep.StateBag) {
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if !cancelled && !halted {
+		return
+	}
+
+	var snapshots []artifactSnapshot
+	if id, ok := state.GetOk("snapshot_id"); ok {
+		c := state.Get("config").(*Config)
+		snapshots = append(snapshots, artifactSnapshot{id: id.(string), name: c.SnapshotName})
+	}
+	dataSnapshots, _ := state.Get("data_snapshots").([]artifactSnapshot)
+	snapshots = append(snapshots, dataSnapshots...)
+
+	ui := state.Get("ui").(packersdk.Ui)
+	for _, snapshot := range snapshots {
+		ui.Say(fmt.Sprintf("Deleting snapshot %s of the failed build...", snapshot.name))
+		if err := deleteSnapshot(context.Background(), s.client, snapshot.id); err != nil {
+			ui.Error(fmt.Sprintf(
+				"Error deleting snapshot %s. Please delete it manually: %s", snapshot.id, err))
+		}
+	}
 }
 
 func processRequestSnapshot(apiClient *ionoscloud.APIClient, resourceID string) (ionoscloud.ResourceHandler, error) {
@@ -141,8 +246,21 @@ func (s *stepTakeSnapshot) getOs(ctx context.Context, dcId string, serverId stri
 	return *volume.Properties.LicenceType, nil
 }
 
-func (s *stepTakeSnapshot) createSnapshot(ctx context.Context, dcId string, volumeId string) (*ionoscloud.Snapshot, error) {
-	snapshot, apiResponse, err := s.client.VolumesApi.DatacentersVolumesCreateSnapshotPost(ctx, dcId, volumeId).Execute()
+// createSnapshot creates a snapshot of a volume and waits for the request to
+// finish. The snapshot is returned along with any error once the API has
+// accepted it, so the caller can delete it.
+func (s *stepTakeSnapshot) createSnapshot(ctx context.Context, dcId, volumeId, name string, opts snapshotOptions) (*ionoscloud.Snapshot, error) {
+	req := s.client.VolumesApi.DatacentersVolumesCreateSnapshotPost(ctx, dcId, volumeId).Name(name)
+	if opts.Description != "" {
+		req = req.Description(opts.Description)
+	}
+	if opts.LicenceType != "" {
+		req = req.LicenceType(opts.LicenceType)
+	}
+	if opts.SecAuthProtection != nil {
+		req = req.SecAuthProtection(*opts.SecAuthProtection)
+	}
+	snapshot, apiResponse, err := req.Execute()
 	if err != nil {
 		return nil, fmt.Errorf(
 			"error creating snapshot (%w)", err)
@@ -151,19 +269,94 @@ func (s *stepTakeSnapshot) createSnapshot(ctx context.Context, dcId string, volu
 	// gets the Location Header value, where Request ID is stored, to interrogate the request status
 	requestPath := getRequestPath(apiResponse)
 	if requestPath == "" {
-		return nil, fmt.Errorf("error getting location from header for datacenter")
+		return &snapshot, fmt.Errorf("error getting location from header for datacenter")
 	}
 
 	// Waits for the snapshot creation to finish. Polls until it receives an answer that
 	// provisioning is successful
 	err = s.waitForRequestToBeDone(ctx, requestPath)
 	if err != nil {
-		return nil, fmt.Errorf("error while waiting for datacenter creation to finish (%w)", err)
+		return &snapshot, fmt.Errorf("error while waiting for datacenter creation to finish (%w)", err)
 	}
 
 	return &snapshot, nil
 }
 
+// patchSnapshot updates the properties of a snapshot and waits for the
+// update to finish.
+func (s *stepTakeSnapshot) patchSnapshot(ctx context.Context, id string, props ionoscloud.SnapshotProperties) error {
+	_, apiResponse, err := s.client.SnapshotsApi.SnapshotsPatch(ctx, id).Snapshot(props).Execute()
+	if err != nil {
+		return fmt.Errorf("error updating snapshot %s: %w", id, err)
+	}
+	requestPath := getRequestPath(apiResponse)
+	if requestPath == "" {
+		return fmt.Errorf("error getting location from header for snapshot %s", id)
+	}
+	return s.waitForRequestToBeDone(ctx, requestPath)
+}
+
+func (s *stepTakeSnapshot) labelSnapshot(ctx context.Context, c *Config, id string) error {
+	return addLabels(c.SnapshotLabels, func(l ionoscloud.LabelResource) error {
+		_, _, err := s.client.LabelsApi.SnapshotsLabelsPost(ctx, id).Label(l).Execute()
+		return err
+	})
+}
+
+// snapshotOptions are the properties a snapshot is created with, besides its
+// name.
+type snapshotOptions struct {
+	Description       string
+	LicenceType       string
+	SecAuthProtection *bool
+}
+
+// snapshotDescriptionData is available to the snapshot_description template.
+type snapshotDescriptionData struct {
+	SnapshotName    string
+	SourceImageID   string
+	SourceImageName string
+	Location        string
+}
+
+// renderSnapshotDescription renders the snapshot_description template, which
+// can refer to the source image of the build.
+func renderSnapshotDescription(c *Config, state multistep.StateBag) (string, error) {
+	if c.SnapshotDescription == "" {
+		return "", nil
+	}
+	sourceImageId, _ := state.Get("source_image_id").(string)
+	sourceImageName, _ := state.Get("source_image_name").(string)
+	c.ctx.Data = &snapshotDescriptionData{
+		SnapshotName:    c.SnapshotName,
+		SourceImageID:   sourceImageId,
+		SourceImageName: sourceImageName,
+		Location:        c.Region,
+	}
+	return interpolate.Render(c.SnapshotDescription, &c.ctx)
+}
+
+// snapshotCapabilities returns the hot-plug capabilities to set on the boot
+// snapshot, or nil if none are configured.
+func snapshotCapabilities(c *Config) *ionoscloud.SnapshotProperties {
+	props := &ionoscloud.SnapshotProperties{
+		CpuHotPlug:          c.SnapshotCpuHotPlug,
+		CpuHotUnplug:        c.SnapshotCpuHotUnplug,
+		RamHotPlug:          c.SnapshotRamHotPlug,
+		RamHotUnplug:        c.SnapshotRamHotUnplug,
+		NicHotPlug:          c.SnapshotNicHotPlug,
+		NicHotUnplug:        c.SnapshotNicHotUnplug,
+		DiscVirtioHotPlug:   c.SnapshotDiscVirtioHotPlug,
+		DiscVirtioHotUnplug: c.SnapshotDiscVirtioHotUnplug,
+		DiscScsiHotPlug:     c.SnapshotDiscScsiHotPlug,
+		DiscScsiHotUnplug:   c.SnapshotDiscScsiHotUnplug,
+	}
+	if *props == (ionoscloud.SnapshotProperties{}) {
+		return nil
+	}
+	return props
+}
+
 // waitForRequestToBeDone - polls until the request is 'Done', or
 // until the context timeout expires
 func (s *stepTakeSnapshot) waitForRequestToBeDone(ctx context.Context, path string) error {
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ionoscloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	ionoscloud "github.com/ionos-cloud/sdk-go/v6"
+)
+
+func TestRenderSnapshotDescription(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["snapshot_description"] = "{{ .SnapshotName }} built from {{ .SourceImageName }} in {{ .Location }}"
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("source_image_name", "Ubuntu-22.04")
+	description, err := renderSnapshotDescription(&b.config, state)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if description != "packer built from Ubuntu-22.04 in us/las" {
+		t.Fatalf("bad description: %q", description)
+	}
+}
+
+func TestSnapshotCapabilities(t *testing.T) {
+	c := &Config{}
+	if props := snapshotCapabilities(c); props != nil {
+		t.Fatalf("should not patch the snapshot without capabilities: %#v", props)
+	}
+
+	c.SnapshotCpuHotPlug = ionoscloud.PtrBool(true)
+	c.SnapshotNicHotUnplug = ionoscloud.PtrBool(false)
+	props := snapshotCapabilities(c)
+	if props == nil || !*props.CpuHotPlug || *props.NicHotUnplug || props.RamHotPlug != nil {
+		t.Fatalf("bad capabilities: %#v", props)
+	}
+}
+
+func TestStepTakeSnapshot_Cleanup(t *testing.T) {
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	step := newStepTakeSnapshot(ionoscloud.NewAPIClient(ionoscloud.NewConfiguration("", "", "token", srv.URL)), nil)
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", packersdk.TestUi(t))
+	state.Put("config", &Config{SnapshotName: "packer"})
+	state.Put("snapshot_id", "boot-id")
+	state.Put("data_snapshots", []artifactSnapshot{{id: "data-id", name: "packer-data", volume: "data"}})
+
+	step.Cleanup(state)
+	if len(deleted) != 0 {
+		t.Fatalf("the snapshots of a successful build should be kept: %v", deleted)
+	}
+
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+	if len(deleted) != 2 || deleted[0] != "/cloudapi/v6/snapshots/boot-id" || deleted[1] != "/cloudapi/v6/snapshots/data-id" {
+		t.Fatalf("the snapshots of a failed build should be deleted: %v", deleted)
+	}
+}
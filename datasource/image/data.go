@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package image
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/ionos-cloud/packer-plugin-ionoscloud/builder/ionoscloud"
+)
+
+type Config struct {
+	ionoscloud.AccessConfig `mapstructure:",squash"`
+	ionoscloud.ImageFilter  `mapstructure:",squash"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+type DatasourceOutput struct {
+	ID          string  `mapstructure:"id"`
+	Name        string  `mapstructure:"name"`
+	Location    string  `mapstructure:"location"`
+	LicenceType string  `mapstructure:"licence_type"`
+	Size        float32 `mapstructure:"size"`
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	var errs *packersdk.MultiError
+	_, es := d.config.AccessConfig.Prepare()
+	errs = packersdk.MultiErrorAppend(errs, es...)
+	errs = packersdk.MultiErrorAppend(errs, d.config.ImageFilter.Prepare()...)
+
+	if d.config.Location == "" {
+		errs = packersdk.MultiErrorAppend(errs, errors.New("'location' is required"))
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	img, err := ionoscloud.FindImage(context.TODO(), d.config.Client(), d.config.ImageFilter)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+
+	output := DatasourceOutput{
+		ID:          img.Id,
+		Name:        img.Name,
+		Location:    img.Location,
+		LicenceType: img.LicenceType,
+		Size:        img.Size,
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package image
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDatasource_Configure(t *testing.T) {
+	d := &Datasource{}
+	err := d.Configure(map[string]interface{}{
+		"token": "token",
+		"name":  "^ubuntu-22\\.04-",
+	})
+	if err == nil {
+		t.Fatal("should have error without location")
+	}
+
+	d = &Datasource{}
+	err = d.Configure(map[string]interface{}{
+		"token":    "token",
+		"name":     "^ubuntu-22\\.04-",
+		"location": "de/fra",
+	})
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if d.config.Visibility != "public" {
+		t.Fatalf("visibility should default to public: %q", d.config.Visibility)
+	}
+}
+
+func TestDatasource_Execute(t *testing.T) {
+	var filter string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("filter.location")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items": [
+			{"id": "1", "metadata": {"createdDate": "2023-09-01T00:00:00Z"}, "properties": {"name": "ubuntu-22.04-20230901", "location": "de/fra", "imageType": "HDD", "licenceType": "LINUX", "public": true, "size": 2.5}},
+			{"id": "2", "metadata": {"createdDate": "2023-10-01T00:00:00Z"}, "properties": {"name": "ubuntu-22.04-20231001", "location": "de/fra", "imageType": "HDD", "licenceType": "LINUX", "public": true, "size": 3}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	d := &Datasource{}
+	err := d.Configure(map[string]interface{}{
+		"token":       "token",
+		"url":         srv.URL,
+		"name":        "^ubuntu-22\\.04-",
+		"location":    "de/fra",
+		"most_recent": true,
+	})
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	out, err := d.Execute()
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if filter != "de/fra" {
+		t.Fatalf("images should be filtered by location, got %q", filter)
+	}
+	if id := out.GetAttr("id").AsString(); id != "2" {
+		t.Fatalf("expected the most recent image, got %s", id)
+	}
+	if size, _ := out.GetAttr("size").AsBigFloat().Float32(); size != 3 {
+		t.Fatalf("bad size: %v", size)
+	}
+	if lt := out.GetAttr("licence_type").AsString(); lt != "LINUX" {
+		t.Fatalf("bad licence type: %s", lt)
+	}
+}
@@ -0,0 +1,90 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package snapshot
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatConfig struct {
+	IonosUsername   *string           `mapstructure:"username" cty:"username" hcl:"username"`
+	IonosPassword   *string           `mapstructure:"password" cty:"password" hcl:"password"`
+	IonosToken      *string           `mapstructure:"token" cty:"token" hcl:"token"`
+	IonosApiUrl     *string           `mapstructure:"url" cty:"url" hcl:"url"`
+	Profile         *string           `mapstructure:"profile" cty:"profile" hcl:"profile"`
+	CredentialsFile *string           `mapstructure:"credentials_file" cty:"credentials_file" hcl:"credentials_file"`
+	Retries         *int              `mapstructure:"retries" cty:"retries" hcl:"retries"`
+	RetryWaitMin    *string           `mapstructure:"retry_wait_min" cty:"retry_wait_min" hcl:"retry_wait_min"`
+	RetryWaitMax    *string           `mapstructure:"retry_wait_max" cty:"retry_wait_max" hcl:"retry_wait_max"`
+	Name            *string           `mapstructure:"name" cty:"name" hcl:"name"`
+	NameRegex       *string           `mapstructure:"name_regex" cty:"name_regex" hcl:"name_regex"`
+	Location        *string           `mapstructure:"location" cty:"location" hcl:"location"`
+	Labels          map[string]string `mapstructure:"labels" cty:"labels" hcl:"labels"`
+	MostRecent      *bool             `mapstructure:"most_recent" cty:"most_recent" hcl:"most_recent"`
+}
+
+// FlatMapstructure returns a new FlatConfig.
+// FlatConfig is an auto-generated flat version of Config.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatConfig)
+}
+
+// HCL2Spec returns the hcl spec of a Config.
+// This spec is used by HCL to read the fields of Config.
+// The decoded values from this spec will then be applied to a FlatConfig.
+func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"username":         &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
+		"password":         &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
+		"token":            &hcldec.AttrSpec{Name: "token", Type: cty.String, Required: false},
+		"url":              &hcldec.AttrSpec{Name: "url", Type: cty.String, Required: false},
+		"profile":          &hcldec.AttrSpec{Name: "profile", Type: cty.String, Required: false},
+		"credentials_file": &hcldec.AttrSpec{Name: "credentials_file", Type: cty.String, Required: false},
+		"retries":          &hcldec.AttrSpec{Name: "retries", Type: cty.Number, Required: false},
+		"retry_wait_min":   &hcldec.AttrSpec{Name: "retry_wait_min", Type: cty.String, Required: false},
+		"retry_wait_max":   &hcldec.AttrSpec{Name: "retry_wait_max", Type: cty.String, Required: false},
+		"name":             &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"name_regex":       &hcldec.AttrSpec{Name: "name_regex", Type: cty.String, Required: false},
+		"location":         &hcldec.AttrSpec{Name: "location", Type: cty.String, Required: false},
+		"labels":           &hcldec.AttrSpec{Name: "labels", Type: cty.Map(cty.String), Required: false},
+		"most_recent":      &hcldec.AttrSpec{Name: "most_recent", Type: cty.Bool, Required: false},
+	}
+	return s
+}
+
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatDatasourceOutput struct {
+	ID          *string  `mapstructure:"id" cty:"id" hcl:"id"`
+	Name        *string  `mapstructure:"name" cty:"name" hcl:"name"`
+	Location    *string  `mapstructure:"location" cty:"location" hcl:"location"`
+	LicenceType *string  `mapstructure:"licence_type" cty:"licence_type" hcl:"licence_type"`
+	Size        *float32 `mapstructure:"size" cty:"size" hcl:"size"`
+	CreatedDate *string  `mapstructure:"created_date" cty:"created_date" hcl:"created_date"`
+}
+
+// FlatMapstructure returns a new FlatDatasourceOutput.
+// FlatDatasourceOutput is an auto-generated flat version of DatasourceOutput.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*DatasourceOutput) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatDatasourceOutput)
+}
+
+// HCL2Spec returns the hcl spec of a DatasourceOutput.
+// This spec is used by HCL to read the fields of DatasourceOutput.
+// The decoded values from this spec will then be applied to a FlatDatasourceOutput.
+func (*FlatDatasourceOutput) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"id":           &hcldec.AttrSpec{Name: "id", Type: cty.String, Required: false},
+		"name":         &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"location":     &hcldec.AttrSpec{Name: "location", Type: cty.String, Required: false},
+		"licence_type": &hcldec.AttrSpec{Name: "licence_type", Type: cty.String, Required: false},
+		"size":         &hcldec.AttrSpec{Name: "size", Type: cty.Number, Required: false},
+		"created_date": &hcldec.AttrSpec{Name: "created_date", Type: cty.String, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/cloudapi/v6/snapshots":
+			_, _ = w.Write([]byte(`{"items": [
+				{"id": "1", "metadata": {"createdDate": "2023-09-01T00:00:00Z"}, "properties": {"name": "base-hardened-1", "location": "de/fra", "licenceType": "LINUX", "size": 10}},
+				{"id": "2", "metadata": {"createdDate": "2023-10-01T00:00:00Z"}, "properties": {"name": "base-hardened-2", "location": "de/fra", "licenceType": "LINUX", "size": 12}}
+			]}`))
+		case "/cloudapi/v6/labels":
+			_, _ = w.Write([]byte(`{"items": [
+				{"properties": {"resourceId": "1", "resourceType": "snapshot", "key": "stage", "value": "prod"}}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestDatasource_Configure(t *testing.T) {
+	d := &Datasource{}
+	err := d.Configure(map[string]interface{}{
+		"token":      "token",
+		"name":       "base-hardened-1",
+		"name_regex": "^base-",
+	})
+	if err == nil {
+		t.Fatal("should have error")
+	}
+	for _, msg := range []string{"'name' or 'name_regex'", "'location' is required"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("error should contain %q: %s", msg, err)
+		}
+	}
+}
+
+func TestDatasource_Execute(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	cases := []struct {
+		name     string
+		config   map[string]interface{}
+		expected string
+		err      string
+	}{
+		{"ambiguous", map[string]interface{}{"name_regex": "^base-hardened"}, "", "2 snapshots in de/fra match"},
+		{"most recent", map[string]interface{}{"name_regex": "^base-hardened", "most_recent": true}, "2", ""},
+		{"name", map[string]interface{}{"name": "base-hardened-1"}, "1", ""},
+		{"labels", map[string]interface{}{"labels": map[string]string{"stage": "prod"}}, "1", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config["token"] = "token"
+			tc.config["url"] = srv.URL
+			tc.config["location"] = "de/fra"
+
+			d := &Datasource{}
+			if err := d.Configure(tc.config); err != nil {
+				t.Fatalf("should not have error: %s", err)
+			}
+			out, err := d.Execute()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("should not have error: %s", err)
+			}
+			if id := out.GetAttr("id").AsString(); id != tc.expected {
+				t.Fatalf("expected snapshot %s, got %s", tc.expected, id)
+			}
+		})
+	}
+}
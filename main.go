@@ -6,6 +6,8 @@ package main
 import (
 	"fmt"
 	"github.com/ionos-cloud/packer-plugin-ionoscloud/builder/ionoscloud"
+	"github.com/ionos-cloud/packer-plugin-ionoscloud/datasource/image"
+	"github.com/ionos-cloud/packer-plugin-ionoscloud/datasource/snapshot"
 	scaffoldingVersion "github.com/ionos-cloud/packer-plugin-ionoscloud/version"
 	"os"
 
@@ -15,6 +17,8 @@ import (
 func main() {
 	pps := plugin.NewSet()
 	pps.RegisterBuilder(plugin.DEFAULT_NAME, new(ionoscloud.Builder))
+	pps.RegisterDatasource("image", new(image.Datasource))
+	pps.RegisterDatasource("snapshot", new(snapshot.Datasource))
 	pps.SetVersion(scaffoldingVersion.PluginVersion)
 	err := pps.Run()
 	if err != nil {